@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunByLanguage(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"a.go":  "package a\n\n// doc\n",
+		"b.go":  "package b\n",
+		"c.py":  "# c\nx = 1\n",
+		"d.txt": "plain\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out, errOut bytes.Buffer
+	cfg := config{recursive: true, modes: modes{code: true}, byLang: true}
+	if err := run([]string{dir}, cfg, strings.NewReader(""), &out, &errOut); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := "2 1 1 Go\n1 0 0 Other\n1 1 0 Python\n4 2 1 total\n"
+	if out.String() != exp {
+		t.Errorf("Expected %q but got %q", exp, out.String())
+	}
+}
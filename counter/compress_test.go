@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const compressedText = "one two three\nfour five\nsix\n"
+
+func gzipped(t *testing.T, data string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(data)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func zstded(t *testing.T, data string) []byte {
+	t.Helper()
+
+	zw, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zw.Close()
+
+	return zw.EncodeAll([]byte(data), nil)
+}
+
+func TestRunCompressed(t *testing.T) {
+	dir := t.TempDir()
+
+	// names carry no extension, formats are told apart by their magic bytes ->
+	gz := filepath.Join(dir, "gz-data")
+	zst := filepath.Join(dir, "zst-data")
+	bz2 := "./testdata/compressed/sample.txt.bz2"
+
+	if err := os.WriteFile(gz, gzipped(t, compressedText), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(zst, zstded(t, compressedText), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name  string
+		files []string
+		stdin []byte
+		exp   string
+	}{
+		{name: "Gzip", files: []string{gz}, exp: "3 6 " + gz + "\n"},
+		{name: "Zstd", files: []string{zst}, exp: "3 6 " + zst + "\n"},
+		{name: "Bzip2", files: []string{bz2}, exp: "3 6 " + bz2 + "\n"},
+		{name: "Stdin", stdin: gzipped(t, compressedText), exp: "3 6\n"},
+		// text that merely starts like a bzip2 header is left alone ->
+		{name: "LooksLikeBzip2", stdin: []byte("BZh9 is not a header\n"), exp: "1 5\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			cfg := config{modes: modes{lines: true, words: true}}
+
+			if err := run(tc.files, cfg, bytes.NewReader(tc.stdin), &out, &errOut); err != nil {
+				t.Fatalf("Unexpected error %q: %s", err, errOut.String())
+			}
+
+			if out.String() != tc.exp {
+				t.Errorf("Expected %q but got %q", tc.exp, out.String())
+			}
+		})
+	}
+}
+
+func TestRunCompressedRecursive(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(dir, "main.go.gz"), gzipped(t, "package main\n\n// doc\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "bin.gz"), gzipped(t, "\x00\x01\x02"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// compressed text is not binary, and keeps the language of the inner extension ->
+	var out, errOut bytes.Buffer
+	cfg := config{recursive: true, modes: modes{code: true}, byLang: true}
+	if err := run([]string{dir}, cfg, strings.NewReader(""), &out, &errOut); err != nil {
+		t.Fatalf("Unexpected error %q: %s", err, errOut.String())
+	}
+
+	exp := "1 1 1 Go\n"
+	if out.String() != exp {
+		t.Errorf("Expected %q but got %q", exp, out.String())
+	}
+}
@@ -0,0 +1,261 @@
+package count
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+)
+
+// language describes just enough of a syntax to tell code, comments and strings apart ->
+type language struct {
+	name   string
+	line   []string
+	block  [][2]string
+	quotes []quote
+	// shell only treats # as a comment at the start of a word ->
+	lineAtWord bool
+}
+
+// quote is a string delimiter, multiLine strings may run over newlines and raw ones
+// have no escapes ->
+type quote struct {
+	delim     string
+	multiLine bool
+	raw       bool
+}
+
+var (
+	cStyleQuotes = []quote{{delim: `"`}, {delim: `'`}}
+
+	languages = []*language{
+		{name: "Go", line: []string{"//"}, block: [][2]string{{"/*", "*/"}},
+			quotes: []quote{{delim: `"`}, {delim: `'`}, {delim: "`", multiLine: true, raw: true}}},
+		{name: "Python", line: []string{"#"},
+			quotes: []quote{{delim: `"""`, multiLine: true}, {delim: `'''`, multiLine: true}, {delim: `"`}, {delim: `'`}}},
+		{name: "JavaScript", line: []string{"//"}, block: [][2]string{{"/*", "*/"}},
+			quotes: []quote{{delim: `"`}, {delim: `'`}, {delim: "`", multiLine: true}}},
+		{name: "TypeScript", line: []string{"//"}, block: [][2]string{{"/*", "*/"}},
+			quotes: []quote{{delim: `"`}, {delim: `'`}, {delim: "`", multiLine: true}}},
+		{name: "C", line: []string{"//"}, block: [][2]string{{"/*", "*/"}}, quotes: cStyleQuotes},
+		{name: "C++", line: []string{"//"}, block: [][2]string{{"/*", "*/"}}, quotes: cStyleQuotes},
+		{name: "Java", line: []string{"//"}, block: [][2]string{{"/*", "*/"}}, quotes: cStyleQuotes},
+		{name: "C#", line: []string{"//"}, block: [][2]string{{"/*", "*/"}}, quotes: cStyleQuotes},
+		{name: "Rust", line: []string{"//"}, block: [][2]string{{"/*", "*/"}}, quotes: []quote{{delim: `"`}}},
+		{name: "Shell", line: []string{"#"}, lineAtWord: true,
+			quotes: []quote{{delim: `"`}, {delim: `'`, raw: true}}},
+		{name: "Markdown", block: [][2]string{{"<!--", "-->"}}},
+	}
+
+	// files with an unknown extension only have code and blank lines ->
+	otherLanguage = &language{name: "Other"}
+
+	extensions = map[string]string{
+		".go":   "Go",
+		".py":   "Python",
+		".js":   "JavaScript",
+		".jsx":  "JavaScript",
+		".mjs":  "JavaScript",
+		".cjs":  "JavaScript",
+		".ts":   "TypeScript",
+		".tsx":  "TypeScript",
+		".c":    "C",
+		".h":    "C",
+		".cc":   "C++",
+		".cpp":  "C++",
+		".cxx":  "C++",
+		".hpp":  "C++",
+		".java": "Java",
+		".cs":   "C#",
+		".rs":   "Rust",
+		".sh":   "Shell",
+		".bash": "Shell",
+		".zsh":  "Shell",
+		".md":   "Markdown",
+	}
+)
+
+// detectLanguage picks the language from the file extension ->
+func detectLanguage(fname string) *language {
+	name, ok := extensions[strings.ToLower(filepath.Ext(trimCompressedExt(fname)))]
+	if !ok {
+		return otherLanguage
+	}
+
+	return languageNamed(name)
+}
+
+// Language names the language a file is counted as, from its extension, "Other" when
+// it is none of the known ones ->
+func Language(fname string) string {
+	return detectLanguage(fname).name
+}
+
+// languageNamed looks up a language given by name, unknown names count as "Other" ->
+func languageNamed(name string) *language {
+	for _, l := range languages {
+		if l.name == name {
+			return l
+		}
+	}
+	return otherLanguage
+}
+
+// longest marker of any language, a write keeps that much minus one back in case a
+// marker is cut in two ->
+const maxMarkerLen = 4
+
+const (
+	stateCode = iota
+	stateLineComment
+	stateBlockComment
+	stateString
+)
+
+// codeCounter sorts lines into code, comment and blank as the input streams past, a
+// line with any code on it is a code line ->
+type codeCounter struct {
+	lang *language
+
+	state int
+	end   string
+	quote quote
+
+	hasCode    bool
+	hasComment bool
+	prev       byte
+	tail       []byte
+
+	code, comment, blank int
+}
+
+func newCodeCounter(lang *language) *codeCounter {
+	return &codeCounter{lang: lang, prev: '\n'}
+}
+
+func (cc *codeCounter) Write(p []byte) (int, error) {
+	n := len(p)
+
+	buf := append(cc.tail, p...)
+	stop := cc.scan(buf, len(buf)-min(len(buf), maxMarkerLen-1))
+	cc.tail = append(cc.tail[:0], buf[stop:]...)
+
+	return n, nil
+}
+
+// flush scans what was held back and counts a last line without a newline ->
+func (cc *codeCounter) flush() {
+	cc.scan(cc.tail, len(cc.tail))
+	cc.tail = cc.tail[:0]
+
+	if cc.hasCode || cc.hasComment {
+		cc.endLine()
+	}
+}
+
+// scan classifies buf[:limit], markers may look ahead into the rest of buf, and returns
+// where it stopped ->
+func (cc *codeCounter) scan(buf []byte, limit int) int {
+	i := 0
+	for i < limit {
+		b := buf[i]
+		rest := buf[i:]
+
+		if b == '\n' {
+			if cc.state == stateLineComment || cc.state == stateString && !cc.quote.multiLine {
+				cc.state = stateCode
+			}
+			cc.endLine()
+			cc.prev = b
+			i++
+			continue
+		}
+
+		step := 1
+		switch cc.state {
+		case stateCode:
+			step = cc.scanCode(rest)
+
+		case stateLineComment:
+			cc.mark(b, &cc.hasComment)
+
+		case stateBlockComment:
+			cc.mark(b, &cc.hasComment)
+			if bytes.HasPrefix(rest, []byte(cc.end)) {
+				cc.state = stateCode
+				step = len(cc.end)
+			}
+
+		case stateString:
+			cc.hasCode = true
+			switch {
+			case b == '\\' && !cc.quote.raw && len(rest) > 1 && rest[1] != '\n':
+				step = 2
+			case bytes.HasPrefix(rest, []byte(cc.quote.delim)):
+				cc.state = stateCode
+				step = len(cc.quote.delim)
+			}
+		}
+
+		cc.prev = buf[i+step-1]
+		i += step
+	}
+
+	return i
+}
+
+// scanCode looks for the start of a comment or string and returns how far to move on ->
+func (cc *codeCounter) scanCode(rest []byte) int {
+	for _, m := range cc.lang.block {
+		if bytes.HasPrefix(rest, []byte(m[0])) {
+			cc.state, cc.end = stateBlockComment, m[1]
+			cc.hasComment = true
+			return len(m[0])
+		}
+	}
+
+	for _, m := range cc.lang.line {
+		if !bytes.HasPrefix(rest, []byte(m)) {
+			continue
+		}
+		if cc.lang.lineAtWord && !isSpace(cc.prev) {
+			continue
+		}
+
+		cc.state = stateLineComment
+		cc.hasComment = true
+		return len(m)
+	}
+
+	for _, q := range cc.lang.quotes {
+		if bytes.HasPrefix(rest, []byte(q.delim)) {
+			cc.state, cc.quote = stateString, q
+			cc.hasCode = true
+			return len(q.delim)
+		}
+	}
+
+	cc.mark(rest[0], &cc.hasCode)
+	return 1
+}
+
+func (cc *codeCounter) mark(b byte, flag *bool) {
+	if !isSpace(b) {
+		*flag = true
+	}
+}
+
+func (cc *codeCounter) endLine() {
+	switch {
+	case cc.hasCode:
+		cc.code++
+	case cc.hasComment:
+		cc.comment++
+	default:
+		cc.blank++
+	}
+	cc.hasCode, cc.hasComment = false, false
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\f' || b == '\v'
+}
@@ -0,0 +1,66 @@
+package count
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestCodeCounter(t *testing.T) {
+	testCases := []struct {
+		name    string
+		fname   string
+		input   string
+		code    int
+		comment int
+		blank   int
+	}{
+		{name: "Go", fname: "x.go",
+			input: "package main\n\n// comment\n/* block\n\n   still */ x := 1\ns := \"// not a comment\"\nr := `raw\n/* in raw */`\n",
+			code:  5, comment: 2, blank: 2,
+		},
+		{name: "Python", fname: "x.py",
+			input: "# comment\nx = '#not'\n\"\"\"doc\n# in doc\n\"\"\"\n\n",
+			code:  4, comment: 1, blank: 1,
+		},
+		{name: "JavaScript", fname: "x.js",
+			input: "/**\n * doc\n */\nconst s = `a\n// b`; // trailing\n",
+			code:  2, comment: 3, blank: 0,
+		},
+		{name: "C", fname: "x.c",
+			input: "int x; /* c */\n/* a */ /* b */\nchar c = '\"'; // q\n",
+			code:  2, comment: 1, blank: 0,
+		},
+		{name: "Shell", fname: "x.sh",
+			input: "#!/bin/sh\necho ${#x} 'a # b' # c\n  # indented\n",
+			code:  1, comment: 2, blank: 0,
+		},
+		{name: "Markdown", fname: "x.md",
+			input: "# Title\n\n<!-- hidden\nnote -->\ntext",
+			code:  2, comment: 2, blank: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// feeding a byte at a time cuts every marker in two somewhere ->
+			readers := []io.Reader{
+				strings.NewReader(tc.input),
+				iotest.OneByteReader(strings.NewReader(tc.input)),
+			}
+
+			for _, r := range readers {
+				res, err := newCounter(t, Options{Code: true, Language: Language(tc.fname)}).Count(r)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if res.Code != tc.code || res.Comment != tc.comment || res.Blank != tc.blank {
+					t.Errorf("Expected %d/%d/%d but got %d/%d/%d", tc.code, tc.comment, tc.blank,
+						res.Code, res.Comment, res.Blank)
+				}
+			}
+		})
+	}
+}
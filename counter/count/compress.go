@@ -0,0 +1,79 @@
+package count
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// magic numbers of the compressed formats counter reads transparently, gzip includes
+// the deflate method byte ->
+var (
+	gzipMagic  = []byte{0x1f, 0x8b, 0x08}
+	bzip2Magic = []byte("BZh")
+	zstdMagic  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+	// bzip2 is only trusted when a block or end of stream marker follows the header,
+	// plenty of text starts with BZh ->
+	bzip2Block = []byte{0x31, 0x41, 0x59, 0x26, 0x53, 0x59}
+	bzip2End   = []byte{0x17, 0x72, 0x45, 0x38, 0x50, 0x90}
+)
+
+// bytes needed to tell all of the formats apart ->
+const magicLen = 10
+
+// extensions left out when guessing the language of a compressed file ->
+var compressedExts = []string{".gz", ".bz2", ".zst", ".zstd"}
+
+// decompress peeks at the start of br and, when it holds a gzip, bzip2 or zstd stream,
+// returns a reader of the decompressed data. The extension plays no part ->
+func decompress(br *bufio.Reader) (io.ReadCloser, bool, error) {
+	head, err := br.Peek(magicLen)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, false, err
+	}
+
+	switch {
+	case bytes.HasPrefix(head, gzipMagic):
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, true, err
+		}
+		return zr, true, nil
+
+	case isBzip2(head):
+		return io.NopCloser(bzip2.NewReader(br)), true, nil
+
+	case bytes.HasPrefix(head, zstdMagic):
+		zr, err := zstd.NewReader(br, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, true, err
+		}
+		return zr.IOReadCloser(), true, nil
+	}
+
+	return io.NopCloser(br), false, nil
+}
+
+func isBzip2(head []byte) bool {
+	if len(head) < magicLen || !bytes.HasPrefix(head, bzip2Magic) || head[3] < '1' || head[3] > '9' {
+		return false
+	}
+	return bytes.Equal(head[4:], bzip2Block) || bytes.Equal(head[4:], bzip2End)
+}
+
+// trimCompressedExt drops a trailing .gz and the like, so a.go.gz is still Go ->
+func trimCompressedExt(fname string) string {
+	for _, ext := range compressedExts {
+		if strings.HasSuffix(strings.ToLower(fname), ext) {
+			return fname[:len(fname)-len(ext)]
+		}
+	}
+	return fname
+}
@@ -0,0 +1,317 @@
+package count
+
+import (
+	"io"
+	"runtime"
+	"sync"
+	"unicode/utf8"
+)
+
+// smallest piece worth handing to its own goroutine ->
+const minChunkSize = 1 << 20
+
+// Result holds every metric gathered in a single pass over the input ->
+type Result struct {
+	Lines int
+	Words int
+	Runes int
+	Bytes int
+	// bytes that are not valid UTF-8, or UTF-16 that could not be transcoded ->
+	Invalid int
+
+	// the language the input was taken for ->
+	Language string
+
+	// lines of code, of comments only and blank lines, with Options.Code ->
+	Code    int
+	Comment int
+	Blank   int
+
+	// matches of Options.Match, or matching lines ->
+	Matches int
+
+	// sentences, paragraphs and the like, with Options.Stats ->
+	Text TextStats
+}
+
+// Add sums o into c ->
+func (c *Result) Add(o Result) {
+	c.Lines += o.Lines
+	c.Words += o.Words
+	c.Runes += o.Runes
+	c.Bytes += o.Bytes
+	c.Invalid += o.Invalid
+	c.Code += o.Code
+	c.Comment += o.Comment
+	c.Blank += o.Blank
+	c.Matches += o.Matches
+	c.Text.Add(o.Text)
+}
+
+// chunk is the raw result of counting one piece of the input, lines being plain newline
+// counts, plus what is needed to stitch it to its neighbours ->
+type chunk struct {
+	Result
+	startsInWord  bool
+	endsInWord    bool
+	endsInNewline bool
+}
+
+// merge joins two adjacent chunks, a word running across the seam is only counted once ->
+func merge(a, b chunk) chunk {
+	if a.Bytes == 0 {
+		return b
+	}
+	if b.Bytes == 0 {
+		return a
+	}
+
+	res := a
+	res.Add(b.Result)
+	if a.endsInWord && b.startsInWord {
+		res.Words--
+	}
+	res.endsInWord = b.endsInWord
+	res.endsInNewline = b.endsInNewline
+
+	return res
+}
+
+// total turns a (possibly merged) chunk into the final counts ->
+func (ch chunk) total() Result {
+	c := ch.Result
+	// a trailing line without a newline still counts as a line ->
+	if c.Bytes > 0 && !ch.endsInNewline {
+		c.Lines++
+	}
+	return c
+}
+
+// countChunk streams r through a counter, memory stays bounded whatever the line or word length ->
+func countChunk(r io.Reader, c *counter) (chunk, error) {
+	_, err := io.Copy(c, r)
+	c.flush()
+
+	return c.chunk, err
+}
+
+// counter is a streaming state machine that can be fed the input in slices of any size ->
+type counter struct {
+	chunk
+	split wordSplitter
+	// bytes of a rune cut off at the end of the previous write ->
+	pending []byte
+
+	// the word being read and the runes that may still join it, only kept when
+	// someone wants the tokens ->
+	onToken func(tok []byte)
+	tok     []byte
+	held    []byte
+
+	// sees every rune along with where it falls in a word, for Options.Stats ->
+	onRune func(r rune, kind runeKind)
+}
+
+func (c *counter) Write(p []byte) (int, error) {
+	n := len(p)
+
+	// finish the rune left over from the previous write first ->
+	if carried := len(c.pending); carried > 0 {
+		buf := append(c.pending, p[:min(len(p), utf8.UTFMax)]...)
+
+		off := 0
+		for off < carried {
+			if !utf8.FullRune(buf[off:]) {
+				c.pending = append(c.pending[:0], buf[off:]...)
+				return n, nil
+			}
+
+			r, size := utf8.DecodeRune(buf[off:])
+			c.rune(r, size)
+			off += size
+		}
+
+		c.pending = c.pending[:0]
+		p = p[off-carried:]
+	}
+
+	for len(p) > 0 {
+		if p[0] < utf8.RuneSelf {
+			c.rune(rune(p[0]), 1)
+			p = p[1:]
+			continue
+		}
+
+		if !utf8.FullRune(p) {
+			c.pending = append(c.pending, p...)
+			break
+		}
+
+		r, size := utf8.DecodeRune(p)
+		c.rune(r, size)
+		p = p[size:]
+	}
+
+	return n, nil
+}
+
+// flush counts whatever is still pending once the input is over, a truncated rune is
+// counted byte by byte as invalid runes ->
+func (c *counter) flush() {
+	for len(c.pending) > 0 {
+		r, size := utf8.DecodeRune(c.pending)
+		c.rune(r, size)
+		c.pending = c.pending[size:]
+	}
+	c.endsInWord = c.split.inWord()
+	c.emit()
+}
+
+func (c *counter) rune(r rune, size int) {
+	c.Bytes += size
+	c.Runes++
+	if r == utf8.RuneError && size == 1 {
+		c.Invalid++
+	}
+
+	c.endsInNewline = r == '\n'
+	if c.endsInNewline {
+		c.Lines++
+	}
+
+	kind := c.split.next(r)
+	if kind == wordStart {
+		c.Words++
+		if c.Runes == 1 {
+			c.startsInWord = true
+		}
+	}
+
+	if c.onToken != nil {
+		c.collect(r, kind)
+	}
+	if c.onRune != nil {
+		c.onRune(r, kind)
+	}
+}
+
+// longest token handed out, anything after that is cut to keep memory bounded ->
+const maxTokenLen = 256
+
+func (c *counter) collect(r rune, kind runeKind) {
+	switch kind {
+	case wordStart:
+		c.emit()
+		c.tok = utf8.AppendRune(c.tok, r)
+	case wordInside:
+		if len(c.tok) < maxTokenLen {
+			c.tok = append(c.tok, c.held...)
+			c.tok = utf8.AppendRune(c.tok, r)
+		}
+		c.held = c.held[:0]
+	case wordHeld:
+		c.held = utf8.AppendRune(c.held, r)
+	default:
+		c.emit()
+	}
+}
+
+// emit hands out the word read so far, dropping runes that never got to join it ->
+func (c *counter) emit() {
+	if len(c.tok) > 0 && c.onToken != nil {
+		c.onToken(c.tok)
+	}
+	c.tok = c.tok[:0]
+	c.held = c.held[:0]
+}
+
+// countParallel counts size bytes of r in pieces of about chunkSize bytes, handing the
+// offsets of every piece counted to done ->
+func countParallel(r io.ReaderAt, size, chunkSize int64, done func(lo, hi int64)) (Result, error) {
+	buf := make([]byte, utf8.UTFMax)
+	peek := func(off int64) ([]byte, error) {
+		n, err := r.ReadAt(buf, off)
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+
+	bounds, err := chunkBounds(size, chunkSize, peek)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return countChunks(bounds, runtime.NumCPU(), func(lo, hi int64) (chunk, error) {
+		ch, err := countChunk(io.NewSectionReader(r, lo, hi-lo), &counter{split: &spaceSplitter{}})
+		if err == nil && done != nil {
+			done(lo, hi)
+		}
+		return ch, err
+	})
+}
+
+// chunkBounds returns the offsets chunks of about chunkSize start at (and the final
+// size), nudged forward so a chunk never starts in the middle of a multibyte rune. peek
+// returns the bytes at an offset ->
+func chunkBounds(size, chunkSize int64, peek func(off int64) ([]byte, error)) ([]int64, error) {
+	bounds := []int64{0}
+
+	for off := chunkSize; off < size; off += chunkSize {
+		head, err := peek(off)
+		if err != nil {
+			return nil, err
+		}
+
+		start := off
+		for i := 0; i < len(head) && i < utf8.UTFMax-1 && !utf8.RuneStart(head[i]); i++ {
+			start++
+		}
+
+		if start > bounds[len(bounds)-1] && start < size {
+			bounds = append(bounds, start)
+		}
+	}
+
+	return append(bounds, size), nil
+}
+
+// countChunks counts the chunks between bounds over as many workers and merges them in
+// order, count is handed the offsets of one chunk ->
+func countChunks(bounds []int64, workers int, count func(lo, hi int64) (chunk, error)) (Result, error) {
+	results := make([]chunk, len(bounds)-1)
+	errs := make([]error, len(bounds)-1)
+
+	// workers pull chunk indexes until there are none left ->
+	wg := sync.WaitGroup{}
+	idxCh := make(chan int)
+
+	go func() {
+		defer close(idxCh)
+		for i := range results {
+			idxCh <- i
+		}
+	}()
+
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range idxCh {
+				results[i], errs[i] = count(bounds[i], bounds[i+1])
+			}
+		}()
+	}
+	wg.Wait()
+
+	var res chunk
+	for i := range results {
+		if errs[i] != nil {
+			return Result{}, errs[i]
+		}
+		res = merge(res, results[i])
+	}
+
+	return res.total(), nil
+}
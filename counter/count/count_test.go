@@ -0,0 +1,362 @@
+package count
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/iotest"
+)
+
+// newCounter is New for options that are known to be valid ->
+func newCounter(tb testing.TB, opts Options) *Counter {
+	tb.Helper()
+
+	c, err := New(opts)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return c
+}
+
+func TestCountWords(t *testing.T) {
+	b := bytes.NewBufferString("one two three\tfour five\n")
+	ans := 5
+
+	res, err := newCounter(t, Options{}).Count(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Words != ans {
+		t.Errorf("Expected %d but got %d", ans, res.Words)
+	}
+}
+
+func TestCountLines(t *testing.T) {
+	b := bytes.NewBufferString("one\n two\n three")
+	ans := 3
+
+	res, err := newCounter(t, Options{}).Count(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Lines != ans {
+		t.Errorf("Expected %d but got %d", ans, res.Lines)
+	}
+}
+
+func TestCountAll(t *testing.T) {
+	b := bytes.NewBufferString("héllo wörld\nça va\n")
+	ans := Result{Lines: 2, Words: 4, Runes: 18, Bytes: 21, Language: "Other"}
+
+	res, err := newCounter(t, Options{}).Count(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != ans {
+		t.Errorf("Expected %+v but got %+v", ans, res)
+	}
+}
+
+func TestCountLongLines(t *testing.T) {
+	// well past bufio.MaxScanTokenSize, which used to stop the count silently ->
+	long := strings.Repeat("x", 1<<20)
+	input := long + "\n" + long + " " + long + "\n"
+	ans := Result{Lines: 2, Words: 3, Runes: len(input), Bytes: len(input), Language: "Other"}
+
+	res, err := newCounter(t, Options{}).Count(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != ans {
+		t.Errorf("Expected %+v but got %+v", ans, res)
+	}
+}
+
+func TestCountSplitReads(t *testing.T) {
+	input := "héllo wörld 日本語\n€ 𝄞 end"
+	c := newCounter(t, Options{})
+
+	exp, err := c.Count(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// runes cut across reads must be put back together ->
+	res, err := c.Count(iotest.OneByteReader(strings.NewReader(input)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != exp {
+		t.Errorf("Expected %+v but got %+v", exp, res)
+	}
+
+	// a truncated rune at the end counts as invalid bytes, not as nothing ->
+	res, err = c.Count(strings.NewReader("ab\xe2\x82"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Runes != 4 || res.Bytes != 4 || res.Invalid != 2 {
+		t.Errorf("Expected 4 runes, 4 bytes and 2 invalid but got %+v", res)
+	}
+}
+
+func TestCountParallel(t *testing.T) {
+	input := "héllo wörld  ça\nva  日本語 text\n\nlast line without newline"
+
+	exp, err := newCounter(t, Options{}).Count(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp.Language = ""
+
+	// every chunk size makes words, runes and lines straddle a seam somewhere ->
+	for size := int64(1); size <= int64(len(input)); size++ {
+		res, err := countParallel(strings.NewReader(input), int64(len(input)), size, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if res != exp {
+			t.Errorf("Chunk size %d: expected %+v but got %+v", size, exp, res)
+		}
+	}
+}
+
+func TestCountFile(t *testing.T) {
+	dir := t.TempDir()
+	text := filepath.Join(dir, "main.go")
+	binary := filepath.Join(dir, "image.png")
+
+	if err := os.WriteFile(text, []byte("package main\n\n// entry\nfunc main() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(binary, []byte("\x89PNG\r\n\x1a\n\x00\x00"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Language", func(t *testing.T) {
+		res, err := newCounter(t, Options{Code: true, Parallel: true}).CountFile(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		exp := Result{Lines: 4, Words: 7, Runes: 38, Bytes: 38, Language: "Go", Code: 2, Comment: 1, Blank: 1}
+		if res != exp {
+			t.Errorf("Expected %+v but got %+v", exp, res)
+		}
+	})
+
+	t.Run("SkipBinary", func(t *testing.T) {
+		c := newCounter(t, Options{SkipBinary: true})
+
+		if _, err := c.CountFile(binary); !errors.Is(err, ErrBinary) {
+			t.Errorf("Expected error %q, got %q instead", ErrBinary, err)
+		}
+		if _, err := c.CountFile(text); err != nil {
+			t.Errorf("Expected %q to be counted but got %q", text, err)
+		}
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		missing := filepath.Join(dir, "missing.txt")
+
+		_, err := newCounter(t, Options{}).CountFile(missing)
+		if !os.IsNotExist(err) || !strings.Contains(err.Error(), missing) {
+			t.Errorf("Expected a not exist error naming %q but got %q", missing, err)
+		}
+	})
+}
+
+func TestStream(t *testing.T) {
+	s := newCounter(t, Options{Stats: true}).Stream("notes.md")
+
+	_, _ = s.Write([]byte("One line. And"))
+	if res := s.Result(); res.Lines != 1 || res.Words != 3 || res.Language != "Markdown" {
+		t.Errorf("Expected 1 line and 3 words of Markdown so far but got %+v", res)
+	}
+
+	_, _ = s.Write([]byte(" more.\n"))
+	res := s.Flush()
+	if res.Lines != 1 || res.Words != 4 || res.Text.Sentences != 2 {
+		t.Errorf("Expected 1 line, 4 words and 2 sentences but got %+v", res)
+	}
+}
+
+func TestNewInvalidEncoding(t *testing.T) {
+	if _, err := New(Options{Encoding: "ebcdic"}); !errors.Is(err, ErrInvalidEncoding) {
+		t.Errorf("Expected error %q, got %q instead", ErrInvalidEncoding, err)
+	}
+}
+
+func TestCountMapped(t *testing.T) {
+	inputs := []string{
+		"héllo wörld  ça\nva  日本語 text\n\nlast line without newline",
+		"no\u00a0break\u3000ideographic\u2028separated\n",
+		"  leading and trailing  \n\n",
+		"bad \xff\xfe bytes \xe2\x82 cut\n",
+	}
+
+	for _, input := range inputs {
+		exp, err := newCounter(t, Options{}).Count(strings.NewReader(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		exp.Language = ""
+
+		// every chunk size makes words, runes and lines straddle a seam somewhere ->
+		for size := 1; size <= len(input); size++ {
+			if res, err := countMapped([]byte(input), size, 2, nil); err != nil || res != exp {
+				t.Errorf("%q in chunks of %d: expected %+v but got %+v", input, size, exp, res)
+			}
+		}
+	}
+
+	t.Run("CountFile", func(t *testing.T) {
+		fname := filepath.Join(t.TempDir(), "mapped.txt")
+		if err := os.WriteFile(fname, []byte(inputs[0]), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		exp, err := newCounter(t, Options{}).Count(strings.NewReader(inputs[0]))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, parallel := range []bool{false, true} {
+			res, err := newCounter(t, Options{Parallel: parallel}).CountFile(fname)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res != exp {
+				t.Errorf("Parallel %t: expected %+v but got %+v", parallel, exp, res)
+			}
+		}
+	})
+}
+
+func TestCountMappedTruncated(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "rotated.log")
+	data := bytes.Repeat([]byte("one two three\n"), 4<<10)
+	if err := os.WriteFile(fname, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Open(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	mapped, unmap, err := mapFile(file, len(data))
+	if err != nil {
+		t.Skip("no mmap:", err)
+	}
+	defer unmap()
+
+	// like logrotate's copytruncate, the pages behind the mapping go away ->
+	if err := os.Truncate(fname, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, workers := range []int{1, 2} {
+		if _, err := countMapped(mapped, minChunkSize, workers, nil); !errors.Is(err, errMapFault) {
+			t.Errorf("%d workers: expected error %q, got %v instead", workers, errMapFault, err)
+		}
+	}
+}
+
+func TestCountFileOnRead(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "big.txt")
+	data := bytes.Repeat([]byte("one two three\n"), 3<<20/14)
+	if err := os.WriteFile(fname, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// the bytes sniffed through the buffer are not reported again by the mapped path ->
+	for _, parallel := range []bool{false, true} {
+		var read atomic.Int64
+		opts := Options{Parallel: parallel, OnRead: func(n int) { read.Add(int64(n)) }}
+
+		if _, err := newCounter(t, opts).CountFile(fname); err != nil {
+			t.Fatal(err)
+		}
+		if read.Load() != int64(len(data)) {
+			t.Errorf("Parallel %t: expected %d bytes read but got %d", parallel, len(data), read.Load())
+		}
+	}
+}
+
+// -benchsize picks the size of the benchmark input, such as 4294967296 to measure the
+// paths on multi-GB files ->
+var benchSize = flag.Int64("benchsize", 64<<20, "Size in bytes of the benchmark input")
+
+// benchmarkFile writes a large text file to compare the streaming and mapped paths on ->
+func benchmarkFile(b *testing.B) string {
+	b.Helper()
+
+	fname := filepath.Join(b.TempDir(), "bench.txt")
+	file, err := os.Create(fname)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer file.Close()
+
+	line := []byte(strings.Repeat("lorem ipsum dolor sit amet, consectetur adipiscing élit\n", 16))
+	size := *benchSize / int64(len(line)) * int64(len(line))
+	for written := int64(0); written < size; written += int64(len(line)) {
+		if _, err := file.Write(line); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.SetBytes(size)
+
+	return fname
+}
+
+// BenchmarkCount streams the file through bufio, as for pipes ->
+func BenchmarkCount(b *testing.B) {
+	fname := benchmarkFile(b)
+	c := newCounter(b, Options{})
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		file, err := os.Open(fname)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		if _, err := c.Count(file); err != nil {
+			b.Fatal(err)
+		}
+		_ = file.Close()
+	}
+}
+
+func BenchmarkCountMapped(b *testing.B) {
+	fname := benchmarkFile(b)
+	c := newCounter(b, Options{})
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := c.CountFile(fname); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCountParallel(b *testing.B) {
+	fname := benchmarkFile(b)
+	c := newCounter(b, Options{Parallel: true})
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := c.CountFile(fname); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
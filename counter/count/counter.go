@@ -0,0 +1,352 @@
+// Package count counts lines, words, runes and bytes the way wc does, and on request
+// code and comment lines, regular expression matches and readability statistics.
+//
+// Options picks what is gathered and New checks them once. The Counter it returns
+// holds no state between inputs, so one Counter counts any number of them:
+//
+//	c, err := count.New(count.Options{Tokenizer: count.Unicode, Stats: true})
+//	if err != nil {
+//		return err
+//	}
+//
+//	res, err := c.CountFile("notes.md")
+//	if err != nil {
+//		return err
+//	}
+//	fmt.Println(res.Lines, res.Words, res.Text.ReadingEase())
+//
+// Count and CountFile see through gzip, bzip2 and zstd compression and transcode
+// UTF-16 and Latin-1 to UTF-8, by Options.Encoding or a byte order mark. When only
+// the plain counts are asked for, CountFile maps regular files into memory, and
+// spreads them over all CPUs with Options.Parallel.
+// Stream counts input that arrives a piece at a time, such as a file being written.
+//
+// A Result holds every count, the fields the Options did not ask for are zero, and
+// Result.Add sums the results of several inputs into a total.
+package count
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+)
+
+// buffer in front of every input, big enough to sniff for binary data ->
+const readBufSize = 64 << 10
+
+// as much of a file as git looks at to decide it is binary ->
+const sniffLen = 8000
+
+// errors ->
+var (
+	ErrBinary          = errors.New("binary file")
+	ErrInvalidEncoding = errors.New("invalid input encoding")
+)
+
+// Options selects what a Counter gathers on top of lines, words, runes and bytes ->
+type Options struct {
+	Tokenizer Tokenizer
+	// Encoding of the input, one of Encodings, empty to go by the byte order mark ->
+	Encoding string
+
+	// Code sorts lines into code, comment and blank. Language forces the language,
+	// otherwise it comes from the file name ->
+	Code     bool
+	Language string
+
+	// Match counts the matches of a regular expression, or the lines that match with
+	// MatchLines, or the lines that do not with InvertMatch ->
+	Match       *regexp.Regexp
+	MatchLines  bool
+	InvertMatch bool
+
+	// Stats gathers sentences, paragraphs and the readability scores ->
+	Stats bool
+
+	// Parallel counts regular files in chunks over all CPUs, when nothing else asked
+	// for needs the input in order ->
+	Parallel bool
+	// SkipBinary makes CountFile return ErrBinary for files that do not look like text ->
+	SkipBinary bool
+
+	// OnToken is handed every word as it is read, cut to 256 bytes ->
+	OnToken func(tok []byte)
+	// OnRead is told how many bytes of the input, as stored, were read. It may be
+	// called from several goroutines at once ->
+	OnRead func(n int)
+}
+
+// Counter counts inputs with a fixed set of options, it holds no state between them ->
+type Counter struct {
+	opts Options
+}
+
+// New returns a Counter for opts ->
+func New(opts Options) (*Counter, error) {
+	if opts.Encoding != "" && !slices.Contains(Encodings, opts.Encoding) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidEncoding, opts.Encoding)
+	}
+
+	return &Counter{opts: opts}, nil
+}
+
+// Count reads r once, it may be compressed or in another encoding ->
+func (c *Counter) Count(r io.Reader) (Result, error) {
+	br := bufio.NewReaderSize(watch(r, c.opts.OnRead), readBufSize)
+	zr, compressed, err := decompress(br)
+	if err != nil {
+		return Result{}, err
+	}
+	defer zr.Close()
+
+	src := br
+	if compressed {
+		src = bufio.NewReaderSize(zr, readBufSize)
+	}
+
+	src, tr, _, err := decode(src, c.opts.Encoding)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return c.countReader(src, c.language(""), tr)
+}
+
+// CountFile opens and counts a single file, errors carry its name ->
+func (c *Counter) CountFile(fname string) (Result, error) {
+	file, err := os.Open(fname)
+	if err != nil {
+		return Result{}, err
+	}
+	defer file.Close()
+
+	br := bufio.NewReaderSize(watch(file, c.opts.OnRead), readBufSize)
+	r, compressed, err := decompress(br)
+	if err != nil {
+		return Result{}, fmt.Errorf("%s: %w", fname, err)
+	}
+	defer r.Close()
+
+	src := br
+	if compressed {
+		src = bufio.NewReaderSize(r, readBufSize)
+	}
+
+	src, tr, bom, err := decode(src, c.opts.Encoding)
+	if err != nil {
+		return Result{}, fmt.Errorf("%s: %w", fname, err)
+	}
+
+	// only text is counted, once decompressed and transcoded ->
+	if c.opts.SkipBinary {
+		binary, err := isBinary(src)
+		if err != nil {
+			return Result{}, fmt.Errorf("%s: %w", fname, err)
+		}
+		if binary {
+			return Result{}, ErrBinary
+		}
+	}
+
+	// the fast path only knows whitespace separated words, and the other counters need
+	// the input in order ->
+	var res Result
+	fast := false
+	o := c.opts
+	if o.Tokenizer == Whitespace && o.OnToken == nil && !o.Code && o.Match == nil && !o.Stats &&
+		!compressed && tr == nil && !bom {
+		res, fast, err = countRegular(file, o.Parallel, o.OnRead)
+		res.Language = c.language(fname).name
+	}
+	if !fast && err == nil {
+		res, err = c.countReader(src, c.language(fname), tr)
+	}
+	if err != nil {
+		return res, fmt.Errorf("%s: %w", fname, err)
+	}
+
+	return res, nil
+}
+
+func (c *Counter) language(fname string) *language {
+	if c.opts.Language != "" {
+		return languageNamed(c.opts.Language)
+	}
+	return detectLanguage(fname)
+}
+
+// countReader counts r in a single pass, adding what the transcoder found invalid ->
+func (c *Counter) countReader(r io.Reader, lang *language, tr *transcoder) (Result, error) {
+	s := c.newStream(lang)
+	_, err := io.Copy(s, r)
+
+	res := s.Flush()
+	if tr != nil {
+		res.Invalid += tr.invalid
+	}
+	return res, err
+}
+
+// Stream counts text written to it a piece at a time, as it arrives. It is decoded like
+// Count does, by Options.Encoding or a byte order mark at the start. fname only picks
+// the language ->
+func (c *Counter) Stream(fname string) *Stream {
+	s := c.newStream(c.language(fname))
+	s.sniffed = false
+	return s
+}
+
+// Stream feeds the input to every counter the options need ->
+type Stream struct {
+	enc string
+	// the first bytes, held back until they tell whether there is a byte order mark ->
+	head    []byte
+	sniffed bool
+	tr      *transcoder
+
+	lang *language
+	c    *counter
+	cc   *codeCounter
+	mc   *matchCounter
+	tc   *textCounter
+}
+
+func (c *Counter) newStream(lang *language) *Stream {
+	o := c.opts
+	// the counting paths decode before they write, only Stream leaves it to the stream ->
+	s := &Stream{enc: o.Encoding, sniffed: true, lang: lang, c: &counter{split: newSplitter(o.Tokenizer), onToken: o.OnToken}}
+
+	if o.Code {
+		s.cc = newCodeCounter(lang)
+	}
+	if o.Match != nil {
+		s.mc = newMatchCounter(o.Match, o.MatchLines, o.InvertMatch)
+	}
+	if o.Stats {
+		s.tc = newTextCounter()
+		s.c.onRune = s.tc.rune
+	}
+
+	return s
+}
+
+func (s *Stream) Write(b []byte) (int, error) {
+	if !s.sniffed {
+		s.head = append(s.head, b...)
+		if maybeBOM(s.head) {
+			return len(b), nil
+		}
+		s.sniff()
+		return len(b), nil
+	}
+
+	if s.tr != nil {
+		s.count(s.tr.push(b, false))
+	} else {
+		s.count(b)
+	}
+	return len(b), nil
+}
+
+// sniff drops a byte order mark, picks the encoding and counts the bytes held back ->
+func (s *Stream) sniff() {
+	mark, enc := sniffBOM(s.head, s.enc)
+	s.sniffed = true
+	s.tr = transcoderFor(nil, enc)
+
+	head := s.head[len(mark):]
+	s.head = nil
+	_, _ = s.Write(head)
+}
+
+// count feeds UTF-8 to the counters ->
+func (s *Stream) count(b []byte) {
+	_, _ = s.c.Write(b)
+	if s.cc != nil {
+		_, _ = s.cc.Write(b)
+	}
+	if s.mc != nil {
+		_, _ = s.mc.Write(b)
+	}
+}
+
+// Result returns what was counted so far, lines still being written only show up in
+// the code and match counts once they end ->
+func (s *Stream) Result() Result {
+	res := s.c.total()
+	res.Language = s.lang.name
+	if s.tr != nil {
+		res.Invalid += s.tr.invalid
+	}
+
+	if s.cc != nil {
+		res.Code, res.Comment, res.Blank = s.cc.code, s.cc.comment, s.cc.blank
+	}
+	if s.mc != nil {
+		res.Matches = s.mc.matches
+	}
+	if s.tc != nil {
+		res.Text = s.tc.TextStats
+	}
+
+	return res
+}
+
+// Flush ends the input and returns the final result ->
+func (s *Stream) Flush() Result {
+	if !s.sniffed {
+		s.sniff()
+	}
+	if s.tr != nil {
+		s.count(s.tr.push(nil, true))
+	}
+
+	s.c.flush()
+	if s.cc != nil {
+		s.cc.flush()
+	}
+	if s.mc != nil {
+		s.mc.flush()
+	}
+	if s.tc != nil {
+		s.tc.flush()
+	}
+
+	return s.Result()
+}
+
+// watch reports what is read from r to onRead, if there is one ->
+func watch(r io.Reader, onRead func(n int)) io.Reader {
+	if onRead == nil {
+		return r
+	}
+	return &watchedReader{r: r, onRead: onRead}
+}
+
+type watchedReader struct {
+	r      io.Reader
+	onRead func(n int)
+}
+
+func (w *watchedReader) Read(p []byte) (int, error) {
+	n, err := w.r.Read(p)
+	if n > 0 {
+		w.onRead(n)
+	}
+	return n, err
+}
+
+// isBinary sniffs the start of a file for a NUL byte, which text files never have ->
+func isBinary(br *bufio.Reader) (bool, error) {
+	head, err := br.Peek(min(sniffLen, br.Size()))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+
+	return bytes.IndexByte(head, 0) >= 0, nil
+}
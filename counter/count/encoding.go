@@ -0,0 +1,203 @@
+package count
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// input encodings Options.Encoding takes, everything is transcoded to UTF-8 first ->
+const (
+	EncodingUTF8    = "utf-8"
+	EncodingUTF16LE = "utf-16le"
+	EncodingUTF16BE = "utf-16be"
+	EncodingLatin1  = "latin1"
+)
+
+var Encodings = []string{EncodingUTF8, EncodingUTF16LE, EncodingUTF16BE, EncodingLatin1}
+
+var (
+	bomUTF8    = []byte{0xef, 0xbb, 0xbf}
+	bomUTF16LE = []byte{0xff, 0xfe}
+	bomUTF16BE = []byte{0xfe, 0xff}
+)
+
+// decode strips a byte order mark and returns br transcoded to UTF-8. Without an
+// encoding the mark picks the encoding, UTF-8 when there is none. tr is nil when nothing has to be
+// transcoded and bom tells whether a mark was dropped ->
+func decode(br *bufio.Reader, enc string) (src *bufio.Reader, tr *transcoder, bom bool, err error) {
+	head, err := br.Peek(len(bomUTF8))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, nil, false, err
+	}
+
+	mark, enc := sniffBOM(head, enc)
+	if _, err := br.Discard(len(mark)); err != nil {
+		return nil, nil, false, err
+	}
+	bom = mark != nil
+
+	tr = transcoderFor(br, enc)
+	if tr == nil {
+		return br, nil, bom, nil
+	}
+	return bufio.NewReaderSize(tr, readBufSize), tr, bom, nil
+}
+
+// sniffBOM returns the byte order mark head starts with, if it agrees with enc, and
+// the encoding it implies ->
+func sniffBOM(head []byte, enc string) ([]byte, string) {
+	switch {
+	case bytes.HasPrefix(head, bomUTF8) && (enc == "" || enc == EncodingUTF8):
+		return bomUTF8, EncodingUTF8
+	case bytes.HasPrefix(head, bomUTF16LE) && (enc == "" || enc == EncodingUTF16LE):
+		return bomUTF16LE, EncodingUTF16LE
+	case bytes.HasPrefix(head, bomUTF16BE) && (enc == "" || enc == EncodingUTF16BE):
+		return bomUTF16BE, EncodingUTF16BE
+	}
+	return nil, enc
+}
+
+// maybeBOM tells whether head could still grow into a byte order mark ->
+func maybeBOM(head []byte) bool {
+	for _, mark := range [][]byte{bomUTF8, bomUTF16LE, bomUTF16BE} {
+		if len(head) < len(mark) && bytes.HasPrefix(mark, head) {
+			return true
+		}
+	}
+	return false
+}
+
+// transcoderFor returns a transcoder reading r, nil for UTF-8 ->
+func transcoderFor(r io.Reader, enc string) *transcoder {
+	switch enc {
+	case EncodingUTF16LE:
+		return newTranscoder(r, binary.LittleEndian)
+	case EncodingUTF16BE:
+		return newTranscoder(r, binary.BigEndian)
+	case EncodingLatin1:
+		return newTranscoder(r, nil)
+	}
+	return nil
+}
+
+// transcoder turns UTF-16 in either byte order, or Latin-1 when order is nil, into
+// UTF-8. Unpaired surrogates and a dangling odd byte become U+FFFD and are counted as
+// invalid ->
+type transcoder struct {
+	r     io.Reader
+	order binary.ByteOrder
+
+	// raw input, n bytes of it still waiting for the rest of a code unit or pair ->
+	buf []byte
+	n   int
+	// transcoded output not yet read, and the buffer behind it ->
+	out     []byte
+	scratch []byte
+	err     error
+
+	invalid int
+}
+
+func newTranscoder(r io.Reader, order binary.ByteOrder) *transcoder {
+	return &transcoder{r: r, order: order, buf: make([]byte, readBufSize)}
+}
+
+func (t *transcoder) Read(p []byte) (int, error) {
+	for len(t.out) == 0 && t.err == nil {
+		n, err := t.r.Read(t.buf[t.n:])
+		t.n += n
+		t.err = err
+		t.convert(err != nil)
+	}
+
+	if len(t.out) == 0 {
+		return 0, t.err
+	}
+
+	n := copy(p, t.out)
+	t.out = t.out[n:]
+	return n, nil
+}
+
+// convert transcodes what is in buf, holding back a trailing partial unit or a high
+// surrogate unless the input is over ->
+func (t *transcoder) convert(final bool) {
+	out := t.scratch[:0]
+	in := t.buf[:t.n]
+
+	if t.order == nil {
+		for _, b := range in {
+			out = utf8.AppendRune(out, rune(b))
+		}
+		t.finish(out, nil)
+		return
+	}
+
+	i := 0
+	for i+2 <= len(in) {
+		r := rune(t.order.Uint16(in[i:]))
+		i += 2
+
+		if utf16.IsSurrogate(r) {
+			switch {
+			case r < 0xdc00 && i+2 <= len(in):
+				if pair := utf16.DecodeRune(r, rune(t.order.Uint16(in[i:]))); pair != utf8.RuneError {
+					r = pair
+					i += 2
+					break
+				}
+				r = t.bad()
+			case r < 0xdc00 && !final:
+				// wait for the low half ->
+				i -= 2
+				t.finish(out, in[i:])
+				return
+			default:
+				r = t.bad()
+			}
+		}
+
+		out = utf8.AppendRune(out, r)
+	}
+
+	if final && i < len(in) {
+		out = utf8.AppendRune(out, t.bad())
+		i = len(in)
+	}
+	t.finish(out, in[i:])
+}
+
+// push transcodes b when the transcoder is written to rather than reading, final
+// ends the input. The result is only valid until the next call ->
+func (t *transcoder) push(b []byte, final bool) []byte {
+	var res []byte
+
+	for {
+		k := copy(t.buf[t.n:], b)
+		t.n += k
+		b = b[k:]
+
+		t.convert(final && len(b) == 0)
+		res = append(res, t.out...)
+		t.out = nil
+
+		if len(b) == 0 {
+			return res
+		}
+	}
+}
+
+func (t *transcoder) finish(out, rest []byte) {
+	t.out, t.scratch = out, out
+	t.n = copy(t.buf, rest)
+}
+
+func (t *transcoder) bad() rune {
+	t.invalid++
+	return utf8.RuneError
+}
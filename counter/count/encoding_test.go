@@ -0,0 +1,111 @@
+package count
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"testing"
+	"testing/iotest"
+	"unicode/utf16"
+)
+
+func utf16Bytes(s string, bigEndian bool) []byte {
+	var b []byte
+	for _, u := range utf16.Encode([]rune(s)) {
+		if bigEndian {
+			b = append(b, byte(u>>8), byte(u))
+		} else {
+			b = append(b, byte(u), byte(u>>8))
+		}
+	}
+	return b
+}
+
+func TestDecode(t *testing.T) {
+	text := "héllo wörld 😀\n"
+
+	testCases := []struct {
+		name    string
+		enc     string
+		input   []byte
+		exp     string
+		invalid int
+	}{
+		{name: "UTF8", input: []byte(text), exp: text},
+		{name: "UTF8BOM", input: append([]byte{0xef, 0xbb, 0xbf}, text...), exp: text},
+		{name: "UTF16LEBOM", input: append([]byte{0xff, 0xfe}, utf16Bytes(text, false)...), exp: text},
+		{name: "UTF16BEBOM", input: append([]byte{0xfe, 0xff}, utf16Bytes(text, true)...), exp: text},
+		{name: "UTF16LE", enc: EncodingUTF16LE, input: utf16Bytes(text, false), exp: text},
+		{name: "UTF16BE", enc: EncodingUTF16BE, input: utf16Bytes(text, true), exp: text},
+		{name: "Latin1", enc: EncodingLatin1, input: []byte("caf\xe9 \xbfqu\xe9?"), exp: "café ¿qué?"},
+		{name: "LoneSurrogate", enc: EncodingUTF16LE, input: []byte{'a', 0, 0x00, 0xd8, 'b', 0},
+			exp: "a�b", invalid: 1},
+		{name: "HighSurrogateAtEnd", enc: EncodingUTF16LE, input: []byte{'a', 0, 0x00, 0xd8},
+			exp: "a�", invalid: 1},
+		{name: "OddByte", enc: EncodingUTF16BE, input: []byte{0, 'a', 0}, exp: "a�", invalid: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// one byte at a time splits every unit and surrogate pair ->
+			br := bufio.NewReader(iotest.OneByteReader(bytes.NewReader(tc.input)))
+
+			src, tr, _, err := decode(br, tc.enc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			res, err := io.ReadAll(src)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(res) != tc.exp {
+				t.Errorf("Expected %q but got %q", tc.exp, res)
+			}
+			if tr != nil && tr.invalid != tc.invalid {
+				t.Errorf("Expected %d invalid but got %d", tc.invalid, tr.invalid)
+			}
+		})
+	}
+}
+
+func TestStreamDecode(t *testing.T) {
+	text := "héllo wörld 😀\nsecond line\n"
+
+	testCases := []struct {
+		name  string
+		enc   string
+		input []byte
+	}{
+		{name: "UTF8", input: []byte(text)},
+		{name: "UTF8BOM", input: append([]byte{0xef, 0xbb, 0xbf}, text...)},
+		{name: "UTF16LEBOM", input: append([]byte{0xff, 0xfe}, utf16Bytes(text, false)...)},
+		{name: "UTF16BEBOM", input: append([]byte{0xfe, 0xff}, utf16Bytes(text, true)...)},
+		{name: "UTF16LE", enc: EncodingUTF16LE, input: utf16Bytes(text, false)},
+		{name: "Latin1", enc: EncodingLatin1, input: []byte("caf\xe9 cr\xe8me\n")},
+		{name: "Short", input: []byte{0xef}},
+		{name: "DanglingByte", enc: EncodingUTF16LE, input: []byte{'a', 0, 'b'}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newCounter(t, Options{Encoding: tc.enc})
+
+			exp, err := c.Count(bytes.NewReader(tc.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// a byte at a time splits the mark, code units and surrogate pairs ->
+			s := c.Stream("")
+			for i := range tc.input {
+				_, _ = s.Write(tc.input[i : i+1])
+			}
+
+			if res := s.Flush(); res != exp {
+				t.Errorf("Expected %+v but got %+v", exp, res)
+			}
+		})
+	}
+}
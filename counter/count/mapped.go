@@ -0,0 +1,165 @@
+package count
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"unicode"
+	"unicode/utf8"
+)
+
+// asciiSpace marks the bytes unicode.IsSpace is true for, the rest of the spaces are
+// multibyte ->
+var asciiSpace = [utf8.RuneSelf]bool{'\t': true, '\n': true, '\v': true, '\f': true, '\r': true, ' ': true}
+
+// biggest piece of a file counted at once, so progress is reported along the way ->
+const maxChunkSize = 16 << 20
+
+// countRegular is the fast path for whitespace words, a regular file is mapped into
+// memory and scanned in place, spread over all CPUs when parallel is set. ok is false
+// when the file has to be streamed instead, such as pipes and empty files ->
+func countRegular(file *os.File, parallel bool, onRead func(n int)) (res Result, ok bool, err error) {
+	info, err := file.Stat()
+	if err != nil {
+		return Result{}, false, err
+	}
+
+	size := info.Size()
+	if !info.Mode().IsRegular() || size == 0 || size > math.MaxInt {
+		return Result{}, false, nil
+	}
+
+	// what sniffing the header pulled through the buffer was reported already ->
+	seen, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return Result{}, false, err
+	}
+	report := func(lo, hi int64) {
+		if n := hi - max(lo, seen); onRead != nil && n > 0 {
+			onRead(int(n))
+		}
+	}
+
+	workers := 1
+	if parallel {
+		workers = runtime.NumCPU()
+	}
+
+	data, unmap, err := mapFile(file, int(size))
+	if err != nil {
+		// without mmap only the parallel path is worth it over streaming ->
+		if !parallel {
+			return Result{}, false, nil
+		}
+
+		chunkSize := max(min(size/int64(workers), maxChunkSize), minChunkSize)
+		res, err := countParallel(file, size, chunkSize, report)
+		return res, true, err
+	}
+	defer unmap()
+
+	chunkSize := max(min(len(data)/workers, maxChunkSize), minChunkSize)
+	res, err = countMapped(data, chunkSize, workers, report)
+	// the file shrank under the mapping, what is left of it is streamed instead ->
+	if errors.Is(err, errMapFault) {
+		return Result{}, false, nil
+	}
+	return res, true, err
+}
+
+// errMapFault is a read of a mapping that failed, such as past the end of a file that
+// was truncated while it was counted ->
+var errMapFault = errors.New("mapped file changed while it was read")
+
+// countMapped counts data in pieces of about chunkSize bytes over as many workers,
+// handing the offsets of every piece counted to done ->
+func countMapped(data []byte, chunkSize, workers int, done func(lo, hi int64)) (Result, error) {
+	peek := func(off int64) ([]byte, error) {
+		return data[off:min(off+utf8.UTFMax, int64(len(data)))], nil
+	}
+
+	var bounds []int64
+	err := guardFault(func() (err error) {
+		bounds, err = chunkBounds(int64(len(data)), int64(chunkSize), peek)
+		return err
+	})
+	if err != nil {
+		return Result{}, err
+	}
+
+	return countChunks(bounds, workers, func(lo, hi int64) (ch chunk, err error) {
+		err = guardFault(func() error {
+			ch = countBytes(data[lo:hi])
+			return nil
+		})
+		if err == nil && done != nil {
+			done(lo, hi)
+		}
+		return ch, err
+	})
+}
+
+// guardFault runs f, turning a fault on a mapping into errMapFault instead of letting
+// the runtime kill the process. It holds for the calling goroutine only ->
+func guardFault(f func() error) (err error) {
+	defer debug.SetPanicOnFault(debug.SetPanicOnFault(true))
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		// only faults carry the address, anything else is a bug ->
+		if _, ok := r.(interface{ Addr() uintptr }); !ok {
+			panic(r)
+		}
+		err = errMapFault
+	}()
+
+	return f()
+}
+
+// countBytes counts a piece of memory, lines with the vectorized bytes.Count and words
+// in one tight loop. Invalid UTF-8 goes through the streaming counter, which knows how
+// to count it ->
+func countBytes(data []byte) chunk {
+	if !utf8.Valid(data) {
+		c := &counter{split: &spaceSplitter{}}
+		_, _ = c.Write(data)
+		c.flush()
+		return c.chunk
+	}
+
+	var ch chunk
+	ch.Bytes = len(data)
+	ch.Lines = bytes.Count(data, []byte{'\n'})
+	ch.endsInNewline = len(data) > 0 && data[len(data)-1] == '\n'
+
+	inWord := false
+	for i := 0; i < len(data); {
+		var space bool
+		if b := data[i]; b < utf8.RuneSelf {
+			space = asciiSpace[b]
+			i++
+		} else {
+			r, size := utf8.DecodeRune(data[i:])
+			space = unicode.IsSpace(r)
+			i += size
+		}
+
+		if !space && !inWord {
+			ch.Words++
+			if ch.Runes == 0 {
+				ch.startsInWord = true
+			}
+		}
+		inWord = !space
+		ch.Runes++
+	}
+	ch.endsInWord = inWord
+
+	return ch
+}
@@ -0,0 +1,113 @@
+package count
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// lines up to matchWindow bytes are matched as a whole, longer ones are searched in
+// windows that overlap by matchOverlap bytes on each side, so only a match longer than
+// the overlap that runs across a window edge can be missed ->
+const (
+	matchWindow  = 1 << 20
+	matchOverlap = 4 << 10
+)
+
+// matchCounter counts regular expression matches line by line as the input streams
+// past, memory stays bounded whatever the line length ->
+type matchCounter struct {
+	re *regexp.Regexp
+	// count matching lines rather than every occurrence ->
+	lines  bool
+	invert bool
+
+	line []byte
+	// where the part of line this window is responsible for starts ->
+	owned int
+	// a window of the current line has already been searched ->
+	partial     bool
+	lineMatched bool
+
+	matches int
+}
+
+func newMatchCounter(re *regexp.Regexp, lines, invert bool) *matchCounter {
+	return &matchCounter{re: re, lines: lines || invert, invert: invert}
+}
+
+func (mc *matchCounter) Write(p []byte) (int, error) {
+	n := len(p)
+
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			mc.line = append(mc.line, p...)
+			if len(mc.line) >= matchWindow+2*matchOverlap {
+				mc.slide()
+			}
+			break
+		}
+
+		mc.line = append(mc.line, p[:i]...)
+		mc.endLine()
+		p = p[i+1:]
+	}
+
+	return n, nil
+}
+
+// flush counts a last line without a newline ->
+func (mc *matchCounter) flush() {
+	if len(mc.line) > 0 || mc.partial {
+		mc.endLine()
+	}
+}
+
+// slide searches the current window of a long line and keeps only its tail, the start of
+// the next window is never owned so a ^ can not match in the middle of a line ->
+func (mc *matchCounter) slide() {
+	end := len(mc.line) - matchOverlap
+	mc.record(mc.search(mc.owned, end))
+
+	mc.line = append(mc.line[:0], mc.line[end-matchOverlap:]...)
+	mc.owned = matchOverlap
+	mc.partial = true
+}
+
+func (mc *matchCounter) endLine() {
+	mc.record(mc.search(mc.owned, len(mc.line)))
+
+	if mc.lines && mc.lineMatched != mc.invert {
+		mc.matches++
+	}
+
+	mc.line = mc.line[:0]
+	mc.owned = 0
+	mc.partial = false
+	mc.lineMatched = false
+}
+
+func (mc *matchCounter) record(n int) {
+	if n > 0 {
+		mc.lineMatched = true
+	}
+	if !mc.lines {
+		mc.matches += n
+	}
+}
+
+// search counts the matches in the window that start in [from, to) ->
+func (mc *matchCounter) search(from, to int) int {
+	// a line that already matched needs no more searching in line mode ->
+	if mc.lines && mc.lineMatched {
+		return 0
+	}
+
+	n := 0
+	for _, loc := range mc.re.FindAllIndex(mc.line, -1) {
+		if loc[0] >= from && loc[0] < to {
+			n++
+		}
+	}
+	return n
+}
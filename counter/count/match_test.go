@@ -0,0 +1,40 @@
+package count
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestMatchLongLine(t *testing.T) {
+	// one line of several windows, with matches right at the window edges ->
+	line := strings.Repeat("x", matchWindow-3) + "abc" + strings.Repeat("y", 3*matchWindow) + "abc"
+	input := line + "\n" + line
+
+	testCases := []struct {
+		name    string
+		pattern string
+		lines   bool
+		exp     int
+	}{
+		{name: "Occurrences", pattern: "abc", exp: 4},
+		{name: "Lines", pattern: "abc", lines: true, exp: 2},
+		{name: "Anchored", pattern: "^y", exp: 0},
+		{name: "End", pattern: "c$", exp: 2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newCounter(t, Options{Match: regexp.MustCompile(tc.pattern), MatchLines: tc.lines})
+
+			res, err := c.Count(strings.NewReader(input))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if res.Matches != tc.exp {
+				t.Errorf("Expected %d but got %d", tc.exp, res.Matches)
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package count
+
+import (
+	"errors"
+	"os"
+)
+
+// mapFile is not supported here, regular files are read instead ->
+func mapFile(file *os.File, size int) (data []byte, unmap func() error, err error) {
+	return nil, nil, errors.ErrUnsupported
+}
@@ -0,0 +1,18 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package count
+
+import (
+	"os"
+	"syscall"
+)
+
+// mapFile maps size bytes of file read only, unmap releases them ->
+func mapFile(file *os.File, size int) (data []byte, unmap func() error, err error) {
+	data, err = syscall.Mmap(int(file.Fd()), 0, size, syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return data, func() error { return syscall.Munmap(data) }, nil
+}
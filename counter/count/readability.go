@@ -0,0 +1,199 @@
+package count
+
+import (
+	"strings"
+	"unicode"
+)
+
+// TextStats holds the raw tallies behind the readability report, the averages and
+// scores are worked out from them so sums over several inputs stay exact ->
+type TextStats struct {
+	Sentences   int
+	Paragraphs  int
+	LongestLine int
+	// only words with a letter in them count towards the scores ->
+	Words     int
+	Letters   int
+	Syllables int
+}
+
+// Add sums o into t, the longest line is the longest of both ->
+func (t *TextStats) Add(o TextStats) {
+	t.Sentences += o.Sentences
+	t.Paragraphs += o.Paragraphs
+	t.LongestLine = max(t.LongestLine, o.LongestLine)
+	t.Words += o.Words
+	t.Letters += o.Letters
+	t.Syllables += o.Syllables
+}
+
+func (t TextStats) WordsPerSentence() float64 {
+	if t.Sentences == 0 {
+		return 0
+	}
+	return float64(t.Words) / float64(t.Sentences)
+}
+
+func (t TextStats) WordLength() float64 {
+	if t.Words == 0 {
+		return 0
+	}
+	return float64(t.Letters) / float64(t.Words)
+}
+
+func (t TextStats) SyllablesPerWord() float64 {
+	if t.Words == 0 {
+		return 0
+	}
+	return float64(t.Syllables) / float64(t.Words)
+}
+
+// ReadingEase is the Flesch reading ease, higher is easier and 60 to 70 is plain English ->
+func (t TextStats) ReadingEase() float64 {
+	if t.Words == 0 {
+		return 0
+	}
+	return 206.835 - 1.015*t.WordsPerSentence() - 84.6*t.SyllablesPerWord()
+}
+
+// GradeLevel is the Flesch-Kincaid grade, roughly the US school year needed to follow
+// the text ->
+func (t TextStats) GradeLevel() float64 {
+	if t.Words == 0 {
+		return 0
+	}
+	return 0.39*t.WordsPerSentence() + 11.8*t.SyllablesPerWord() - 15.59
+}
+
+// closing punctuation that may follow the end of a sentence, as in `"Stop."` ->
+const sentenceClosers = `"')]}’”»`
+
+// textCounter gathers sentences, paragraphs and syllables from the runes and word
+// boundaries the counter finds. A sentence ends at . ! or ? followed by a space, or at
+// the end of a paragraph, and a paragraph is a run of lines that are not blank ->
+type textCounter struct {
+	TextStats
+
+	lineLen   int
+	lineBlank bool
+	inPara    bool
+
+	inWord           bool
+	wordLetters      int
+	wordSyllables    int
+	prevVowel        bool
+	last, beforeLast rune
+
+	sentenceWords int
+	endPending    bool
+}
+
+func newTextCounter() *textCounter {
+	return &textCounter{lineBlank: true}
+}
+
+func (tc *textCounter) rune(r rune, kind runeKind) {
+	if tc.inWord && kind != wordInside && kind != wordHeld {
+		tc.endWord()
+	}
+	if kind == wordStart {
+		tc.inWord = true
+	}
+
+	if r == '\n' {
+		tc.endLine()
+		return
+	}
+
+	if r != '\r' {
+		tc.lineLen++
+	}
+	if !unicode.IsSpace(r) && tc.lineBlank {
+		tc.lineBlank = false
+		if !tc.inPara {
+			tc.Paragraphs++
+			tc.inPara = true
+		}
+	}
+
+	if tc.inWord && unicode.IsLetter(r) {
+		tc.letter(unicode.ToLower(r))
+	}
+
+	switch {
+	case r == '.' || r == '!' || r == '?':
+		tc.endPending = tc.sentenceWords > 0 || tc.wordLetters > 0
+	case tc.endPending && unicode.IsSpace(r):
+		tc.endSentence()
+	case tc.endPending && strings.ContainsRune(sentenceClosers, r):
+	default:
+		tc.endPending = false
+	}
+}
+
+// letter counts syllables as groups of vowels ->
+func (tc *textCounter) letter(r rune) {
+	tc.wordLetters++
+
+	vowel := strings.ContainsRune("aeiouyàáâäèéêëìíîïòóôöùúûü", r)
+	if vowel && !tc.prevVowel {
+		tc.wordSyllables++
+	}
+	tc.prevVowel = vowel
+	tc.beforeLast, tc.last = tc.last, r
+}
+
+func (tc *textCounter) endWord() {
+	tc.inWord = false
+	if tc.wordLetters == 0 {
+		return
+	}
+
+	// a final e is mostly silent, as in "make" but not "table" ->
+	syl := tc.wordSyllables
+	if tc.last == 'e' && tc.beforeLast != 'l' && syl > 1 {
+		syl--
+	}
+
+	tc.Words++
+	tc.Letters += tc.wordLetters
+	tc.Syllables += max(syl, 1)
+	tc.sentenceWords++
+
+	tc.wordLetters, tc.wordSyllables = 0, 0
+	tc.prevVowel, tc.last, tc.beforeLast = false, 0, 0
+}
+
+func (tc *textCounter) endSentence() {
+	if tc.sentenceWords > 0 {
+		tc.Sentences++
+	}
+	tc.sentenceWords = 0
+	tc.endPending = false
+}
+
+func (tc *textCounter) endLine() {
+	tc.LongestLine = max(tc.LongestLine, tc.lineLen)
+
+	// a heading or list item without a full stop still ends at the blank line after it ->
+	if tc.lineBlank && tc.inPara {
+		tc.endSentence()
+		tc.inPara = false
+	} else if tc.endPending {
+		tc.endSentence()
+	}
+
+	tc.lineLen = 0
+	tc.lineBlank = true
+}
+
+// flush ends the last word, line and sentence ->
+func (tc *textCounter) flush() {
+	if tc.inWord {
+		tc.endWord()
+	}
+	if tc.lineLen > 0 {
+		tc.LongestLine = max(tc.LongestLine, tc.lineLen)
+	}
+	tc.endSentence()
+}
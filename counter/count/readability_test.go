@@ -0,0 +1,55 @@
+package count
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestTextStats(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+		exp   TextStats
+	}{
+		{name: "Empty", input: "", exp: TextStats{}},
+		{name: "Sentences", input: "The cat sat. It ran away!\nDid it?",
+			exp: TextStats{Sentences: 3, Paragraphs: 1, LongestLine: 25, Words: 8, Letters: 23, Syllables: 9}},
+		{name: "Paragraphs", input: "# Title\n\nOne line\nand another.\n\n\nLast one\n",
+			exp: TextStats{Sentences: 3, Paragraphs: 3, LongestLine: 12, Words: 7, Letters: 29, Syllables: 10}},
+		{name: "Abbreviations", input: "Pi is 3.14 or so.",
+			exp: TextStats{Sentences: 1, Paragraphs: 1, LongestLine: 17, Words: 4, Letters: 8, Syllables: 4}},
+		{name: "Quoted", input: `He said "stop." Then left.`,
+			exp: TextStats{Sentences: 2, Paragraphs: 1, LongestLine: 26, Words: 5, Letters: 18, Syllables: 5}},
+		{name: "SilentE", input: "make table time\n",
+			exp: TextStats{Sentences: 1, Paragraphs: 1, LongestLine: 15, Words: 3, Letters: 13, Syllables: 4}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := iotest.OneByteReader(strings.NewReader(tc.input))
+
+			res, err := newCounter(t, Options{Stats: true}).Count(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if res.Text != tc.exp {
+				t.Errorf("Expected %+v but got %+v", tc.exp, res.Text)
+			}
+		})
+	}
+}
+
+func TestReadabilityScores(t *testing.T) {
+	// 2 sentences, 10 words and 14 syllables ->
+	tc := TextStats{Sentences: 2, Words: 10, Syllables: 14}
+
+	if ease := math.Round(tc.ReadingEase()*10) / 10; ease != 83.3 {
+		t.Errorf("Expected reading ease 83.3 but got %.1f", ease)
+	}
+	if grade := math.Round(tc.GradeLevel()*10) / 10; grade != 2.9 {
+		t.Errorf("Expected grade level 2.9 but got %.1f", grade)
+	}
+}
@@ -0,0 +1,275 @@
+package count
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Tokenizer picks how text is split into words ->
+type Tokenizer int
+
+const (
+	// Whitespace splits words on runs of whitespace, like wc ->
+	Whitespace Tokenizer = iota
+	// Unicode splits words on UAX #29 word boundaries, leaving punctuation out ->
+	Unicode
+)
+
+// runeKind is where a rune stands relative to the words around it ->
+type runeKind int
+
+const (
+	wordOutside runeKind = iota
+	wordStart
+	wordInside
+	// the rune joins the current word only if the one after it does too ->
+	wordHeld
+)
+
+// wordSplitter decides where words start while runes stream past ->
+type wordSplitter interface {
+	// next reports how r relates to the current word ->
+	next(r rune) runeKind
+	// inWord reports whether the input so far ends inside a word ->
+	inWord() bool
+}
+
+func newSplitter(t Tokenizer) wordSplitter {
+	if t == Unicode {
+		return &uaxSplitter{}
+	}
+	return &spaceSplitter{}
+}
+
+// spaceSplitter is the classic behaviour, words are runs of anything but whitespace ->
+type spaceSplitter struct {
+	in bool
+}
+
+func (s *spaceSplitter) next(r rune) runeKind {
+	if unicode.IsSpace(r) {
+		s.in = false
+		return wordOutside
+	}
+
+	if s.in {
+		return wordInside
+	}
+	s.in = true
+	return wordStart
+}
+
+func (s *spaceSplitter) inWord() bool {
+	return s.in
+}
+
+// word break property values from UAX #29, only the ones that matter for counting ->
+type wbClass int
+
+const (
+	wbOther wbClass = iota
+	wbALetter
+	wbNumeric
+	wbKatakana
+	wbExtendNumLet
+	wbMidLetter
+	wbMidNum
+	wbMidNumLet
+	wbExtend
+	// Han and Hiragana are Other in UAX #29, so every one of them is a segment of its own,
+	// they are kept apart here only because they still count as words ->
+	wbIdeographic
+)
+
+// tables for the properties the unicode package has no ready made category for ->
+var (
+	wbMidLetterTable = &unicode.RangeTable{
+		R16: []unicode.Range16{
+			{Lo: 0x003a, Hi: 0x003a, Stride: 1},
+			{Lo: 0x00b7, Hi: 0x00b7, Stride: 1},
+			{Lo: 0x0387, Hi: 0x0387, Stride: 1},
+			{Lo: 0x055f, Hi: 0x055f, Stride: 1},
+			{Lo: 0x05f4, Hi: 0x05f4, Stride: 1},
+			{Lo: 0x2027, Hi: 0x2027, Stride: 1},
+			{Lo: 0xfe13, Hi: 0xfe13, Stride: 1},
+			{Lo: 0xfe55, Hi: 0xfe55, Stride: 1},
+			{Lo: 0xff1a, Hi: 0xff1a, Stride: 1},
+		},
+	}
+	wbMidNumTable = &unicode.RangeTable{
+		R16: []unicode.Range16{
+			{Lo: 0x002c, Hi: 0x002c, Stride: 1},
+			{Lo: 0x003b, Hi: 0x003b, Stride: 1},
+			{Lo: 0x037e, Hi: 0x037e, Stride: 1},
+			{Lo: 0x0589, Hi: 0x0589, Stride: 1},
+			{Lo: 0x060c, Hi: 0x060d, Stride: 1},
+			{Lo: 0x066c, Hi: 0x066c, Stride: 1},
+			{Lo: 0x07f8, Hi: 0x07f8, Stride: 1},
+			{Lo: 0x2044, Hi: 0x2044, Stride: 1},
+			{Lo: 0xfe10, Hi: 0xfe10, Stride: 1},
+			{Lo: 0xfe14, Hi: 0xfe14, Stride: 1},
+			{Lo: 0xfe50, Hi: 0xfe50, Stride: 1},
+			{Lo: 0xfe54, Hi: 0xfe54, Stride: 1},
+			{Lo: 0xff0c, Hi: 0xff0c, Stride: 1},
+			{Lo: 0xff1b, Hi: 0xff1b, Stride: 1},
+		},
+	}
+	wbMidNumLetTable = &unicode.RangeTable{
+		R16: []unicode.Range16{
+			{Lo: 0x0027, Hi: 0x0027, Stride: 1},
+			{Lo: 0x002e, Hi: 0x002e, Stride: 1},
+			{Lo: 0x2018, Hi: 0x2019, Stride: 1},
+			{Lo: 0x2024, Hi: 0x2024, Stride: 1},
+			{Lo: 0xfe52, Hi: 0xfe52, Stride: 1},
+			{Lo: 0xff07, Hi: 0xff07, Stride: 1},
+			{Lo: 0xff0e, Hi: 0xff0e, Stride: 1},
+		},
+	}
+	// Katakana word break values that are outside the Katakana script ->
+	wbKatakanaTable = &unicode.RangeTable{
+		R16: []unicode.Range16{
+			{Lo: 0x3031, Hi: 0x3035, Stride: 1},
+			{Lo: 0x309b, Hi: 0x309c, Stride: 1},
+			{Lo: 0x30a0, Hi: 0x30a0, Stride: 1},
+			{Lo: 0x30fc, Hi: 0x30fc, Stride: 1},
+			{Lo: 0xff70, Hi: 0xff70, Stride: 1},
+		},
+	}
+)
+
+func wordClass(r rune) wbClass {
+	// ASCII is by far the most common case ->
+	if r < utf8.RuneSelf {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z':
+			return wbALetter
+		case '0' <= r && r <= '9':
+			return wbNumeric
+		case r == '_':
+			return wbExtendNumLet
+		case r == ':':
+			return wbMidLetter
+		case r == ',' || r == ';':
+			return wbMidNum
+		case r == '.' || r == '\'':
+			return wbMidNumLet
+		}
+		return wbOther
+	}
+
+	switch {
+	case unicode.In(r, unicode.Han, unicode.Hiragana):
+		return wbIdeographic
+	case unicode.In(r, unicode.Katakana, wbKatakanaTable):
+		return wbKatakana
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc, unicode.Cf):
+		return wbExtend
+	case unicode.IsLetter(r):
+		return wbALetter
+	case unicode.Is(unicode.Nd, r):
+		return wbNumeric
+	case unicode.Is(unicode.Pc, r):
+		return wbExtendNumLet
+	case unicode.Is(wbMidLetterTable, r):
+		return wbMidLetter
+	case unicode.Is(wbMidNumTable, r):
+		return wbMidNum
+	case unicode.Is(wbMidNumLetTable, r):
+		return wbMidNumLet
+	}
+	return wbOther
+}
+
+// wordLike tells whether a segment holding this class is a word rather than punctuation ->
+func (c wbClass) wordLike() bool {
+	return c == wbALetter || c == wbNumeric || c == wbKatakana || c == wbIdeographic
+}
+
+// joinsLetters and joinsDigits are the WB6/WB7 and WB11/WB12 middle characters ->
+func (c wbClass) joinsLetters() bool {
+	return c == wbMidLetter || c == wbMidNumLet
+}
+
+func (c wbClass) joinsDigits() bool {
+	return c == wbMidNum || c == wbMidNumLet
+}
+
+// joins reports whether there is no boundary between two adjacent classes ->
+func joins(a, b wbClass) bool {
+	alnum := func(c wbClass) bool { return c == wbALetter || c == wbNumeric }
+
+	switch {
+	case alnum(a) && alnum(b): // WB5, WB8, WB9, WB10
+		return true
+	case a == wbKatakana && b == wbKatakana: // WB13
+		return true
+	case b == wbExtendNumLet: // WB13a
+		return alnum(a) || a == wbKatakana || a == wbExtendNumLet
+	case a == wbExtendNumLet: // WB13b
+		return alnum(b) || b == wbKatakana
+	}
+	return false
+}
+
+// uaxSplitter finds UAX #29 word boundaries in a single pass, a middle character like the
+// apostrophe in "can't" is held back until the rune after it shows whether it joins ->
+type uaxSplitter struct {
+	// last class seen, extending characters do not count ->
+	prev wbClass
+	// middle character waiting on the next rune, wbOther when there is none ->
+	mid wbClass
+	in  bool
+}
+
+func (s *uaxSplitter) next(r rune) runeKind {
+	c := wordClass(r)
+
+	// WB4: marks and format characters stick to whatever came before ->
+	if c == wbExtend {
+		switch {
+		case s.mid != wbOther:
+			return wordHeld
+		case s.in:
+			return wordInside
+		}
+		return wordOutside
+	}
+
+	if s.mid != wbOther {
+		mid := s.mid
+		s.mid = wbOther
+
+		if s.prev == wbALetter && c == wbALetter && mid.joinsLetters() ||
+			s.prev == wbNumeric && c == wbNumeric && mid.joinsDigits() {
+			s.prev = c
+			return wordInside
+		}
+
+		// the middle character turned out to be a segment on its own ->
+		s.prev = wbOther
+		s.in = false
+	}
+
+	if s.in && (s.prev == wbALetter && c.joinsLetters() || s.prev == wbNumeric && c.joinsDigits()) {
+		s.mid = c
+		return wordHeld
+	}
+
+	if !joins(s.prev, c) {
+		s.in = false
+	}
+	s.prev = c
+
+	switch {
+	case s.in:
+		return wordInside
+	case c.wordLike():
+		s.in = true
+		return wordStart
+	}
+	return wordOutside
+}
+
+func (s *uaxSplitter) inWord() bool {
+	return s.in
+}
@@ -0,0 +1,37 @@
+package count
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnicodeWords(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+		exp   int
+	}{
+		{name: "Apostrophe", input: "can't won’t", exp: 2},
+		{name: "Punctuation", input: "hello, world! (yes)", exp: 3},
+		{name: "Numbers", input: "3.14 1,000,000 v1.2", exp: 3},
+		{name: "TrailingMid", input: "end. start: next'", exp: 3},
+		{name: "Underscore", input: "_private snake_case __", exp: 2},
+		{name: "Combining", input: "cafe\u0301 nai\u0308ve", exp: 2},
+		{name: "Han", input: "中文分词", exp: 4},
+		{name: "Katakana", input: "テストとコード", exp: 3},
+		{name: "Dash", input: "well-known — fact", exp: 3},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := newCounter(t, Options{Tokenizer: Unicode}).Count(strings.NewReader(tc.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if res.Words != tc.exp {
+				t.Errorf("Expected %d but got %d", tc.exp, res.Words)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/ankitjha/counter/count"
+)
+
+func utf16Bytes(s string, bigEndian bool) []byte {
+	var b []byte
+	for _, u := range utf16.Encode([]rune(s)) {
+		if bigEndian {
+			b = append(b, byte(u>>8), byte(u))
+		} else {
+			b = append(b, byte(u), byte(u>>8))
+		}
+	}
+	return b
+}
+
+func TestRunEncoding(t *testing.T) {
+	dir := t.TempDir()
+	utf16File := filepath.Join(dir, "export.txt")
+	invalidFile := filepath.Join(dir, "invalid.txt")
+
+	data := append([]byte{0xff, 0xfe}, utf16Bytes("one two\r\nthree\r\n", false)...)
+	if err := os.WriteFile(utf16File, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(invalidFile, []byte("ok \xff\xfe bad\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("UTF16", func(t *testing.T) {
+		var out bytes.Buffer
+		cfg := config{modes: modes{lines: true, words: true, runes: true}, parallel: true}
+
+		if err := run([]string{utf16File}, cfg, strings.NewReader(""), &out, &out); err != nil {
+			t.Fatal(err)
+		}
+
+		exp := "2 3 16 " + utf16File + "\n"
+		if out.String() != exp {
+			t.Errorf("Expected %q but got %q", exp, out.String())
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		var out, errOut bytes.Buffer
+		cfg := config{format: formatJSON}
+
+		if err := run([]string{invalidFile}, cfg, strings.NewReader(""), &out, &errOut); err != nil {
+			t.Fatal(err)
+		}
+
+		var res jsonReport
+		if err := json.Unmarshal(out.Bytes(), &res); err != nil {
+			t.Fatal(err)
+		}
+		if res.Files[0].Invalid != 2 || res.Total.Invalid != 2 {
+			t.Errorf("Expected 2 invalid sequences but got %+v", res)
+		}
+	})
+
+	t.Run("Warning", func(t *testing.T) {
+		var out, errOut bytes.Buffer
+
+		if err := run([]string{invalidFile}, config{}, strings.NewReader(""), &out, &errOut); err != nil {
+			t.Fatal(err)
+		}
+
+		exp := invalidFile + ": 2 invalid byte sequences\n"
+		if errOut.String() != exp {
+			t.Errorf("Expected %q but got %q", exp, errOut.String())
+		}
+	})
+
+	t.Run("UnknownEncoding", func(t *testing.T) {
+		var out bytes.Buffer
+
+		err := run(nil, config{encoding: "ebcdic"}, strings.NewReader(""), &out, &out)
+		if !errors.Is(err, count.ErrInvalidEncoding) {
+			t.Errorf("Expected error %q, got %q instead", count.ErrInvalidEncoding, err)
+		}
+	})
+}
@@ -0,0 +1,17 @@
+package main
+
+import "errors"
+
+var (
+	ErrReadFailed      = errors.New("one or more inputs could not be read")
+	ErrInvalidFreq     = errors.New("invalid number of frequent words")
+	ErrInvalidNgram    = errors.New("invalid n-gram size")
+	ErrInvalidFormat   = errors.New("invalid output format")
+	ErrInvalidPattern  = errors.New("invalid match pattern")
+	ErrInvalidFollow   = errors.New("-f follows exactly one named file")
+	ErrInvalidInterval = errors.New("invalid update interval")
+	ErrReadability     = errors.New("readability check failed")
+	ErrInvalidFileList = errors.New("invalid file list")
+	ErrInvalidSnapshot = errors.New("invalid snapshot")
+	ErrInvalidCompare  = errors.New("-compare only covers lines, words, runes and bytes")
+)
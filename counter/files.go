@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// longest name accepted in a file list, well past any real path ->
+const maxListName = 1 << 20
+
+// fileList reads the names of the inputs from -files0-from or -files-from instead of
+// the command line, "-" reading the list from stdin ->
+func fileList(filenames []string, cfg config, stdin io.Reader) ([]string, error) {
+	if cfg.files0From != "" && cfg.filesFrom != "" {
+		return nil, fmt.Errorf("%w: -files0-from and -files-from can not be combined", ErrInvalidFileList)
+	}
+	if len(filenames) > 0 {
+		return nil, fmt.Errorf("%w: file names given along with a list", ErrInvalidFileList)
+	}
+
+	listName, sep := cfg.files0From, byte(0)
+	if cfg.filesFrom != "" {
+		listName, sep = cfg.filesFrom, '\n'
+	}
+
+	r := stdin
+	if listName != "-" {
+		file, err := os.Open(listName)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxListName)
+	scanner.Split(splitOn(sep))
+
+	var names []string
+	for scanner.Scan() {
+		name := scanner.Text()
+		if sep == '\n' {
+			name = strings.TrimSuffix(name, "\r")
+		}
+
+		if name == "" {
+			continue
+		}
+		// stdin can not be both the list and one of the inputs ->
+		if name == "-" && listName == "-" {
+			return nil, fmt.Errorf("%w: - in a list read from stdin", ErrInvalidFileList)
+		}
+
+		names = append(names, name)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", listName, err)
+	}
+
+	return names, nil
+}
+
+// splitOn is a bufio.SplitFunc for entries ended by sep, the last one may lack it ->
+func splitOn(sep byte) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := bytes.IndexByte(data, sep); i >= 0 {
+			return i + 1, data[:i], nil
+		}
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	}
+}
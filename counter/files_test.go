@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunFileList(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.txt")
+	// names from find -print0 may hold anything but NUL ->
+	b := filepath.Join(dir, "b\nc.txt")
+	list0 := filepath.Join(dir, "list0")
+
+	if err := os.WriteFile(a, []byte("one\ntwo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(b, []byte("three\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(list0, []byte(a+"\x00"+b+"\x00"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name   string
+		cfg    config
+		files  []string
+		stdin  string
+		exp    string
+		expErr error
+	}{
+		{name: "Files0From", cfg: config{files0From: list0},
+			exp: "2 " + a + "\n1 " + b + "\n3 total\n"},
+		{name: "Files0FromStdin", cfg: config{files0From: "-"}, stdin: a + "\x00" + b,
+			exp: "2 " + a + "\n1 " + b + "\n3 total\n"},
+		{name: "FilesFromStdin", cfg: config{filesFrom: "-"}, stdin: a + "\r\n\n" + a + "\n",
+			exp: "2 " + a + "\n2 " + a + "\n4 total\n"},
+		{name: "Empty", cfg: config{filesFrom: "-"}, stdin: "", exp: ""},
+		{name: "StdinInStdinList", cfg: config{filesFrom: "-"}, stdin: "-\n", expErr: ErrInvalidFileList},
+		{name: "BothLists", cfg: config{filesFrom: "-", files0From: list0}, expErr: ErrInvalidFileList},
+		{name: "NamesAndList", cfg: config{files0From: list0}, files: []string{a}, expErr: ErrInvalidFileList},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			tc.cfg.modes = modes{lines: true}
+
+			err := run(tc.files, tc.cfg, strings.NewReader(tc.stdin), &out, &out)
+			if tc.expErr != nil {
+				if !errors.Is(err, tc.expErr) {
+					t.Errorf("Expected error %q, got %q instead", tc.expErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %q", err)
+			}
+
+			if out.String() != tc.exp {
+				t.Errorf("Expected %q but got %q", tc.exp, out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/ankitjha/counter/count"
+)
+
+// follower keeps counting a file as it grows, like tail -f, and starts over on the new
+// file when it is rotated or truncated ->
+type follower struct {
+	name    string
+	counter *count.Counter
+
+	file   *os.File
+	info   os.FileInfo
+	offset int64
+	stream *count.Stream
+	buf    []byte
+
+	// counts of the files rotated away, or the contents truncated away ->
+	done count.Result
+}
+
+// bytes read from the followed file at a time ->
+const followBufSize = 64 << 10
+
+func newFollower(name string, counter *count.Counter) (*follower, error) {
+	f := &follower{
+		name:    name,
+		counter: counter,
+		buf:     make([]byte, followBufSize),
+	}
+
+	file, info, err := f.open()
+	if err != nil {
+		return nil, err
+	}
+	f.start(file, info)
+
+	return f, nil
+}
+
+func (f *follower) open() (*os.File, os.FileInfo, error) {
+	file, err := os.Open(f.name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	return file, info, nil
+}
+
+// start counts file from the beginning, what was counted before stays in the totals ->
+func (f *follower) start(file *os.File, info os.FileInfo) {
+	if f.stream != nil {
+		f.done.Add(f.stream.Flush())
+	}
+
+	f.file, f.info, f.offset = file, info, 0
+	f.stream = f.counter.Stream(f.name)
+}
+
+// poll counts whatever was appended since the last call and returns the totals ->
+func (f *follower) poll() (count.Result, error) {
+	// the old file may still get a last write after it was renamed, so drain it first ->
+	if err := f.read(); err != nil {
+		return count.Result{}, err
+	}
+
+	info, err := os.Stat(f.name)
+	switch {
+	// between the rename and the new file showing up there is nothing to follow yet ->
+	case errors.Is(err, fs.ErrNotExist):
+		return f.counts(), nil
+	case err != nil:
+		return count.Result{}, err
+
+	case !os.SameFile(info, f.info):
+		file, info, err := f.open()
+		if errors.Is(err, fs.ErrNotExist) {
+			return f.counts(), nil
+		}
+		if err != nil {
+			return count.Result{}, err
+		}
+
+		f.file.Close()
+		f.start(file, info)
+
+	case info.Size() < f.offset:
+		if _, err := f.file.Seek(0, io.SeekStart); err != nil {
+			return count.Result{}, err
+		}
+		f.start(f.file, info)
+
+	default:
+		return f.counts(), nil
+	}
+
+	if err := f.read(); err != nil {
+		return count.Result{}, err
+	}
+	return f.counts(), nil
+}
+
+// read feeds the file to the counters up to its current end ->
+func (f *follower) read() error {
+	for {
+		n, err := f.file.Read(f.buf)
+		if n > 0 {
+			_, _ = f.stream.Write(f.buf[:n])
+			f.offset += int64(n)
+		}
+
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (f *follower) counts() count.Result {
+	res := f.done
+	res.Add(f.stream.Result())
+	return res
+}
+
+func (f *follower) Close() error {
+	return f.file.Close()
+}
+
+// jsonTick is one line of -f -format json output, a JSON object per update ->
+type jsonTick struct {
+	jsonRow
+	LinesPerSec float64 `json:"lines_per_sec"`
+}
+
+// runFollow counts the one named file, then prints updated totals and the rate of new
+// lines every interval until ctx is done ->
+func runFollow(ctx context.Context, filenames []string, cfg config, out io.Writer) error {
+	m, err := selectModes(&cfg)
+	if err != nil {
+		return err
+	}
+
+	if len(filenames) != 1 || filenames[0] == "-" {
+		return ErrInvalidFollow
+	}
+	if cfg.interval <= 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidInterval, cfg.interval)
+	}
+	fname := filenames[0]
+
+	counter, err := count.New(counterOptions(cfg, m, nil))
+	if err != nil {
+		return err
+	}
+
+	f, err := newFollower(fname, counter)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(out)
+	enc := json.NewEncoder(out)
+
+	printTick := func(c count.Result, rate float64) error {
+		switch cfg.format {
+		case formatJSON:
+			return enc.Encode(jsonTick{jsonRow: newJSONRow(fname, c.Language, c, m), LinesPerSec: rate})
+		case formatCSV:
+			rec := append([]string{fname}, metrics(c, m)...)
+			_ = w.Write(append(rec, strconv.FormatFloat(rate, 'f', 1, 64)))
+			w.Flush()
+			return w.Error()
+		}
+
+		_, err := fmt.Fprintf(out, "%s %s %.1f lines/s\n", format(c, m), fname, rate)
+		return err
+	}
+
+	if cfg.format == formatCSV {
+		_ = w.Write(append(append([]string{"name"}, metricNames(m)...), "lines_per_sec"))
+	}
+
+	prev, err := f.poll()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fname, err)
+	}
+	if err := printTick(prev, 0); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(cfg.interval)
+	defer ticker.Stop()
+	last := time.Now()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			c, err := f.poll()
+			if err != nil {
+				return fmt.Errorf("%s: %w", fname, err)
+			}
+
+			rate := float64(c.Lines-prev.Lines) / now.Sub(last).Seconds()
+			if err := printTick(c, rate); err != nil {
+				return err
+			}
+			prev, last = c, now
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ankitjha/counter/count"
+)
+
+func appendFile(t *testing.T, fname, data string) {
+	t.Helper()
+
+	file, err := os.OpenFile(fname, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFollowerPoll(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "app.log")
+	appendFile(t, fname, "one two\n")
+
+	counter, err := count.New(count.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := newFollower(fname, counter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	steps := []struct {
+		name   string
+		change func()
+		lines  int
+		words  int
+	}{
+		{name: "Start", change: func() {}, lines: 1, words: 2},
+		{name: "Append", change: func() { appendFile(t, fname, "three\n") }, lines: 2, words: 3},
+		{name: "PartialLine", change: func() { appendFile(t, fname, "fo") }, lines: 3, words: 4},
+		{name: "FinishLine", change: func() { appendFile(t, fname, "ur five\n") }, lines: 3, words: 5},
+		{name: "Unchanged", change: func() {}, lines: 3, words: 5},
+		{name: "Rotated", change: func() {
+			if err := os.Rename(fname, fname+".1"); err != nil {
+				t.Fatal(err)
+			}
+			appendFile(t, fname+".1", "six\n")
+			appendFile(t, fname, "seven eight\n")
+		}, lines: 5, words: 8},
+		{name: "Truncated", change: func() {
+			if err := os.WriteFile(fname, []byte("nine\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}, lines: 6, words: 9},
+	}
+
+	for _, tc := range steps {
+		tc.change()
+
+		c, err := f.poll()
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+
+		if c.Lines != tc.lines || c.Words != tc.words {
+			t.Errorf("%s: Expected %d lines and %d words but got %d and %d",
+				tc.name, tc.lines, tc.words, c.Lines, c.Words)
+		}
+	}
+}
+
+func TestRunFollow(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "app.log")
+	appendFile(t, fname, "a\nb\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		cfg := config{modes: modes{lines: true}, interval: 10 * time.Millisecond}
+		done <- runFollow(ctx, []string{fname}, cfg, pw)
+		pw.Close()
+	}()
+
+	lines := bufio.NewScanner(pr)
+	if !lines.Scan() {
+		t.Fatal("Expected a first update")
+	}
+	exp := "2 " + fname + " 0.0 lines/s"
+	if lines.Text() != exp {
+		t.Errorf("Expected %q but got %q", exp, lines.Text())
+	}
+
+	appendFile(t, fname, "c\n")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for lines.Scan() {
+		if strings.HasPrefix(lines.Text(), "3 "+fname+" ") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected an update with the appended line")
+		}
+	}
+
+	cancel()
+	go func() { _, _ = io.Copy(io.Discard, pr) }()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("NoFile", func(t *testing.T) {
+		err := runFollow(context.Background(), nil, config{interval: time.Second}, io.Discard)
+		if !errors.Is(err, ErrInvalidFollow) {
+			t.Errorf("Expected error %q, got %q instead", ErrInvalidFollow, err)
+		}
+	})
+}
+
+func TestFollowerEncoding(t *testing.T) {
+	testCases := []struct {
+		name string
+		enc  string
+		head []byte
+	}{
+		{name: "Flag", enc: count.EncodingUTF16LE},
+		{name: "BOM", head: []byte{0xff, 0xfe}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fname := filepath.Join(t.TempDir(), "u16.txt")
+			data := append(tc.head, utf16Bytes("one two\nthree", false)...)
+
+			// the first poll stops in the middle of a code unit ->
+			appendFile(t, fname, string(data[:len(data)-1]))
+
+			counter, err := count.New(count.Options{Encoding: tc.enc})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			f, err := newFollower(fname, counter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			if _, err := f.poll(); err != nil {
+				t.Fatal(err)
+			}
+			appendFile(t, fname, string(data[len(data)-1:]))
+
+			c, err := f.poll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Lines != 2 || c.Words != 3 || c.Runes != 13 || c.Invalid != 0 {
+				t.Errorf("Expected 2 lines, 3 words and 13 runes but got %+v", c)
+			}
+		})
+	}
+}
@@ -1,3 +1,5 @@
 module github.com/ankitjha/counter
 
 go 1.23.5
+
+require github.com/klauspost/compress v1.17.11
@@ -1,30 +1,430 @@
 package main
 
 import (
-	"bufio"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ankitjha/counter/count"
 )
 
+// modes selects which columns get printed, always in the order lines, words, runes, bytes ->
+type modes struct {
+	lines bool
+	words bool
+	runes bool
+	bytes bool
+	// code, comment and blank lines, after the others ->
+	code bool
+	// matches of -match, shown on their own ->
+	match bool
+	// sentences, paragraphs and readability scores ->
+	stats bool
+}
+
+// config carries everything the command line selected ->
+type config struct {
+	modes        modes
+	format       string
+	parallel     bool
+	unicodeWords bool
+	// empty to go by the byte order mark ->
+	encoding string
+
+	// -match mode, re is compiled by run ->
+	pattern    string
+	ignoreCase bool
+	invert     bool
+	re         *regexp.Regexp
+
+	// -stats gates, nil when not set ->
+	minEase  *float64
+	maxGrade *float64
+
+	// progress line on stderr ->
+	progress bool
+
+	// -f mode ->
+	follow   bool
+	interval time.Duration
+
+	// lists of inputs, NUL or newline separated ->
+	files0From string
+	filesFrom  string
+
+	// counts saved by -snapshot and compared against by -compare ->
+	snapshot string
+	compare  string
+
+	// -r mode ->
+	recursive bool
+	include   globs
+	exclude   globs
+	byExt     bool
+	byLang    bool
+
+	// -freq and -ngram modes ->
+	freq     int
+	ngram    int
+	top      int
+	maxKeys  int
+	fold     bool
+	stopFile string
+	minLen   int
+}
+
+// how many rows -ngram reports when -top is not given ->
+const defaultTop = 10
+
 func main() {
+	bytesFlag := flag.Bool("b", false, "Count bytes")
+	runes := flag.Bool("c", false, "Count characters (runes)")
+	words := flag.Bool("w", false, "Count words")
 	lines := flag.Bool("l", false, "Count lines")
+	format := flag.String("format", formatText, "Output format: "+strings.Join(formats, ", "))
+	parallel := flag.Bool("p", false, "Count regular files in parallel chunks")
+	unicodeWords := flag.Bool("unicode-words", false, "Split words on Unicode (UAX #29) word boundaries")
+	encoding := flag.String("encoding", "", "Transcode input from "+strings.Join(count.Encodings, ", ")+" (default: byte order mark, else utf-8)")
+	freq := flag.Int("freq", 0, "Report the N most frequent words instead of counts")
+	ngram := flag.Int("ngram", 0, "Report the most frequent sequences of N words instead of counts")
+	top := flag.Int("top", defaultTop, "Number of rows reported by -ngram")
+	maxKeys := flag.Int("maxkeys", 1<<20, "Distinct words or n-grams kept exactly before counts become approximate")
+	fold := flag.Bool("fold", false, "Fold case when counting word frequencies")
+	stopFile := flag.String("stop", "", "File of stop words left out of word frequencies")
+	minLen := flag.Int("minlen", 0, "Shortest word, in characters, kept in word frequencies")
+	pattern := flag.String("match", "", "Count matches of a regular expression, matching lines with -l")
+	ignoreCase := flag.Bool("i", false, "Match case insensitively")
+	invert := flag.Bool("v", false, "Count lines that do not match")
+	stats := flag.Bool("stats", false, "Report sentences, paragraphs, averages, longest line and readability scores")
+	var minEase, maxGrade *float64
+	flag.Func("min-ease", "Fail when a file scores below this Flesch reading ease (implies -stats)", floatFlag(&minEase))
+	flag.Func("max-grade", "Fail when a file scores above this Flesch-Kincaid grade (implies -stats)", floatFlag(&maxGrade))
+	progressFlag := flag.Bool("progress", false, "Show bytes read, throughput and ETA on stderr while counting")
+	follow := flag.Bool("f", false, "Keep counting the named file as it grows, printing totals and lines/s")
+	interval := flag.Duration("interval", time.Second, "How often -f prints updated totals")
+	files0From := flag.String("files0-from", "", "Read NUL separated input names from this file, - for stdin")
+	filesFrom := flag.String("files-from", "", "Read newline separated input names from this file, - for stdin")
+	snapshotFile := flag.String("snapshot", "", "Save the counts of every file to this JSON file")
+	compareFile := flag.String("compare", "", "Print how lines and words changed since a -snapshot file")
+	recursive := flag.Bool("r", false, "Count every text file under the named directories")
+	byExt := flag.Bool("by-ext", false, "Group rows by file extension")
+	code := flag.Bool("code", false, "Count code, comment and blank lines, by language")
+	byLang := flag.Bool("by-lang", false, "Group rows by language")
+	var include, exclude globs
+	flag.Var(&include, "include", "Only count files matching this glob (repeatable, ** spans directories)")
+	flag.Var(&exclude, "exclude", "Skip files and directories matching this glob (repeatable)")
 	flag.Parse()
 
-	fmt.Println(count(os.Stdin, *lines))
+	cfg := config{
+		modes:        modes{lines: *lines, words: *words, runes: *runes, bytes: *bytesFlag, code: *code, stats: *stats},
+		format:       *format,
+		parallel:     *parallel,
+		unicodeWords: *unicodeWords,
+		encoding:     *encoding,
+		freq:         *freq,
+		ngram:        *ngram,
+		top:          *top,
+		maxKeys:      *maxKeys,
+		fold:         *fold,
+		stopFile:     *stopFile,
+		minLen:       *minLen,
+		pattern:      *pattern,
+		ignoreCase:   *ignoreCase,
+		invert:       *invert,
+		minEase:      minEase,
+		maxGrade:     maxGrade,
+		progress:     *progressFlag,
+		follow:       *follow,
+		interval:     *interval,
+		files0From:   *files0From,
+		filesFrom:    *filesFrom,
+		snapshot:     *snapshotFile,
+		compare:      *compareFile,
+		recursive:    *recursive,
+		include:      include,
+		exclude:      exclude,
+		byExt:        *byExt,
+		byLang:       *byLang,
+	}
+
+	var err error
+	if cfg.follow {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		err = runFollow(ctx, flag.Args(), cfg, os.Stdout)
+		stop()
+	} else {
+		err = run(flag.Args(), cfg, os.Stdin, os.Stdout, os.Stderr)
+	}
+
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 }
 
-func count(r io.Reader, lines bool) int {
-	scanner := bufio.NewScanner(r)
-	if !lines {
-		scanner.Split(bufio.ScanWords)
+// run counts every named input (stdin when none or "-") and reports one row per input,
+// plus a total row when there is more than one ->
+func run(filenames []string, cfg config, stdin io.Reader, out, errOut io.Writer) error {
+	// what changed is lines and words unless other columns were asked for ->
+	if cfg.compare != "" && cfg.modes == (modes{}) {
+		cfg.modes = modes{lines: true, words: true}
+	}
+
+	m, err := selectModes(&cfg)
+	if err != nil {
+		return err
 	}
 
-	var count int
-	for scanner.Scan() {
-		count += 1
+	if cfg.freq < 0 || cfg.top < 0 {
+		return fmt.Errorf("%w: %d", ErrInvalidFreq, min(cfg.freq, cfg.top))
 	}
+	if cfg.ngram < 0 {
+		return fmt.Errorf("%w: %d", ErrInvalidNgram, cfg.ngram)
+	}
+
+	// -freq N is short for -ngram 1 -top N ->
+	n, top := cfg.ngram, cfg.top
+	if cfg.freq > 0 {
+		n, top = max(n, 1), cfg.freq
+	}
+	if top == 0 {
+		top = defaultTop
+	}
+
+	// a snapshot only keeps the plain counts ->
+	if cfg.compare != "" && (m.code || m.match || m.stats || n > 0) {
+		return ErrInvalidCompare
+	}
+
+	// token statistics replace the usual rows ->
+	var stats *tokenStats
+	var onToken func(tok []byte)
+	if n > 0 {
+		s, err := newTokenStats(n, cfg)
+		if err != nil {
+			return err
+		}
+		stats, onToken = s, s.add
+	}
+
+	opts := counterOptions(cfg, m, onToken)
+
+	var prog *progress
+	if cfg.progress {
+		prog = newProgress(errOut)
+		opts.OnRead = prog.add
+	}
+
+	named, err := count.New(opts)
+	if err != nil {
+		return err
+	}
+	// files found by -r are only counted when they look like text ->
+	opts.SkipBinary = true
+	walked, err := count.New(opts)
+	if err != nil {
+		return err
+	}
+
+	// a list of inputs is named even when it is empty, stdin is not read then ->
+	listed := cfg.files0From != "" || cfg.filesFrom != ""
+	if listed {
+		filenames, err = fileList(filenames, cfg, stdin)
+		if err != nil {
+			return err
+		}
+	}
+
+	// like grep, -r without names means the current directory ->
+	if cfg.recursive && len(filenames) == 0 && !listed {
+		filenames = []string{"."}
+	}
+
+	rep := report{named: len(filenames) > 0 || listed, tokenMode: stats != nil}
+	inputs := expand(filenames, cfg)
+
+	// the progress line is cleared before anything is written ->
+	var total, done int64 = -1, 0
+	if prog != nil {
+		if rep.named {
+			total = inputSize(inputs)
+		}
+		prog.run(total)
+		defer prog.finish()
+	}
+
+	// reading stdin keeps the old output of a bare number with no name ->
+	if !rep.named {
+		res, err := named.Count(stdin)
+		rep.rows = append(rep.rows, row{name: "-", lang: res.Language, counts: res, err: err})
+	}
+
+	for _, in := range inputs {
+		if in.err != nil {
+			rep.rows = append(rep.rows, row{name: in.name, err: in.err})
+			continue
+		}
 
-	return count
+		if stats != nil {
+			stats.reset()
+		}
+
+		// keep going so one bad file does not hide the others ->
+		res, err := countFile(in, named, walked, stdin)
+		if prog != nil && total >= 0 {
+			done += fileSize(in.name)
+			prog.settle(done)
+		}
+		if errors.Is(err, count.ErrBinary) {
+			continue
+		}
+		rep.rows = append(rep.rows, row{name: in.name, lang: count.Language(in.name), counts: res, err: err})
+	}
+
+	for _, r := range rep.rows {
+		if r.err == nil {
+			rep.total.Add(r.counts)
+		}
+	}
+
+	if prog != nil {
+		prog.finish()
+	}
+
+	switch {
+	case cfg.byLang:
+		rep.rows = groupRows(rep.rows, func(r row) string { return r.lang })
+	case cfg.byExt:
+		rep.rows = groupRows(rep.rows, func(r row) string { return filepath.Ext(r.name) })
+	}
+
+	if stats != nil {
+		rep.tokens = stats.tally.top(top)
+		rep.approximate = stats.tally.approximate()
+	}
+
+	// the old snapshot is read first, -compare and -snapshot can name the same file to
+	// roll it forward ->
+	var prev map[string]count.Result
+	if cfg.compare != "" {
+		var err error
+		if prev, err = loadSnapshot(cfg.compare); err != nil {
+			return err
+		}
+	}
+
+	if cfg.snapshot != "" {
+		if err := saveSnapshot(cfg.snapshot, rep.rows); err != nil {
+			return err
+		}
+	}
+
+	if cfg.compare != "" {
+		deltas, total := compare(prev, rep.rows, m)
+		if err := writeDeltas(out, errOut, rep, deltas, total, cfg.format, m); err != nil {
+			return err
+		}
+	} else if err := write(out, errOut, rep, cfg.format, m); err != nil {
+		return err
+	}
+
+	if err := rep.err(); err != nil {
+		return err
+	}
+	return checkReadability(rep.rows, cfg)
+}
+
+// floatFlag parses an optional float flag, leaving *p nil when it is not given ->
+func floatFlag(p **float64) func(string) error {
+	return func(s string) error {
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		*p = &v
+		return nil
+	}
+}
+
+// selectModes checks the format and works out the columns to print, compiling the -match pattern into cfg along the way ->
+func selectModes(cfg *config) (modes, error) {
+	if cfg.format == "" {
+		cfg.format = formatText
+	}
+	if !slices.Contains(formats, cfg.format) {
+		return modes{}, fmt.Errorf("%w: %s", ErrInvalidFormat, cfg.format)
+	}
+
+	m := cfg.modes
+	// the readability gates need the scores ->
+	if cfg.minEase != nil || cfg.maxGrade != nil {
+		m.stats = true
+	}
+	// counting words is the default, same as before the other flags existed ->
+	if m == (modes{}) {
+		m.words = true
+	}
+
+	// -match prints the match count alone, -l only switches it to matching lines ->
+	if cfg.pattern != "" {
+		expr := cfg.pattern
+		if cfg.ignoreCase {
+			expr = "(?i)" + expr
+		}
+
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return modes{}, fmt.Errorf("%w: %s", ErrInvalidPattern, err)
+		}
+		cfg.re = re
+		m = modes{match: true}
+	}
+
+	return m, nil
+}
+
+// counterOptions turns the command line into what the count package needs ->
+func counterOptions(cfg config, m modes, onToken func(tok []byte)) count.Options {
+	opts := count.Options{
+		Encoding: cfg.encoding,
+		Code:     m.code,
+		Stats:    m.stats,
+		Parallel: cfg.parallel,
+		OnToken:  onToken,
+	}
+
+	if cfg.unicodeWords {
+		opts.Tokenizer = count.Unicode
+	}
+	if m.match {
+		opts.Match, opts.MatchLines, opts.InvertMatch = cfg.re, cfg.modes.lines, cfg.invert
+	}
+
+	return opts
+}
+
+// countFile counts a single input, "-" being stdin ->
+func countFile(in input, named, walked *count.Counter, stdin io.Reader) (count.Result, error) {
+	if in.name == "-" {
+		res, err := named.Count(stdin)
+		if err != nil {
+			return res, fmt.Errorf("%s: %w", in.name, err)
+		}
+		return res, nil
+	}
+
+	if in.walked {
+		return walked.CountFile(in.name)
+	}
+	return named.CountFile(in.name)
 }
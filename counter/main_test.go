@@ -2,25 +2,113 @@ package main
 
 import (
 	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"testing/iotest"
 )
 
-func TestCountWords(t *testing.T) {
-	b := bytes.NewBufferString("one two three\tfour five\n")
-	ans := 5
+func TestRun(t *testing.T) {
+	input := "one two\nthree\n"
 
-	res := count(b, false)
-	if res != ans {
-		t.Errorf("Expected %d but got %d", ans, res)
+	testCases := []struct {
+		name string
+		m    modes
+		exp  string
+	}{
+		{name: "Default", m: modes{}, exp: "3\n"},
+		{name: "Lines", m: modes{lines: true}, exp: "2\n"},
+		{name: "Bytes", m: modes{bytes: true}, exp: "14\n"},
+		{name: "AllColumns", m: modes{lines: true, words: true, runes: true, bytes: true}, exp: "2 3 14 14\n"},
+		{name: "FixedOrder", m: modes{bytes: true, lines: true}, exp: "2 14\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := run(nil, config{modes: tc.m}, bytes.NewBufferString(input), &out, &out); err != nil {
+				t.Fatal(err)
+			}
+
+			if out.String() != tc.exp {
+				t.Errorf("Expected %q but got %q", tc.exp, out.String())
+			}
+		})
+	}
+}
+
+func TestRunReadError(t *testing.T) {
+	var out, errOut bytes.Buffer
+	r := io.MultiReader(strings.NewReader("one two "), iotest.ErrReader(iotest.ErrTimeout))
+
+	err := run(nil, config{}, r, &out, &errOut)
+	if !errors.Is(err, iotest.ErrTimeout) {
+		t.Errorf("Expected error %q, got %q instead", iotest.ErrTimeout, err)
+	}
+
+	// a partial number would look like a real result ->
+	if out.Len() != 0 {
+		t.Errorf("Expected no output but got %q", out.String())
 	}
 }
 
-func TestCountLines(t *testing.T) {
-	b := bytes.NewBufferString("one\n two\n three")
-	ans := 3
+func TestRunFiles(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.txt")
+	b := filepath.Join(dir, "b.txt")
+	missing := filepath.Join(dir, "missing.txt")
+
+	if err := os.WriteFile(a, []byte("one\ntwo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(b, []byte("three\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name   string
+		files  []string
+		stdin  string
+		exp    string
+		expErr error
+	}{
+		{name: "OneFile", files: []string{a},
+			exp: "2 " + a + "\n",
+		},
+		{name: "MultiFiles", files: []string{a, b},
+			exp: "2 " + a + "\n1 " + b + "\n3 total\n",
+		},
+		{name: "Stdin", files: []string{a, "-"}, stdin: "four\nfive\nsix\n",
+			exp: "2 " + a + "\n3 -\n5 total\n",
+		},
+		{name: "MissingFile", files: []string{a, missing, b},
+			exp:    "2 " + a + "\n1 " + b + "\n3 total\n",
+			expErr: ErrReadFailed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			err := run(tc.files, config{modes: modes{lines: true}}, strings.NewReader(tc.stdin), &out, &errOut)
+
+			if tc.expErr != nil {
+				if !errors.Is(err, tc.expErr) {
+					t.Errorf("Expected error %q, got %q instead", tc.expErr, err)
+				}
+				if !strings.Contains(errOut.String(), missing) {
+					t.Errorf("Expected %q to be reported, got %q", missing, errOut.String())
+				}
+			} else if err != nil {
+				t.Fatalf("Unexpected error: %q", err)
+			}
 
-	res := count(b, true)
-	if res != ans {
-		t.Errorf("Expected %d but got %d", ans, res)
+			if out.String() != tc.exp {
+				t.Errorf("Expected %q but got %q", tc.exp, out.String())
+			}
+		})
 	}
 }
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestRunMatch(t *testing.T) {
+	input := "foo bar foo\nBar\nbaz\nfoo"
+
+	testCases := []struct {
+		name string
+		cfg  config
+		exp  string
+	}{
+		{name: "Occurrences", cfg: config{pattern: "foo"}, exp: "3\n"},
+		{name: "Lines", cfg: config{pattern: "foo", modes: modes{lines: true}}, exp: "2\n"},
+		{name: "Invert", cfg: config{pattern: "foo", invert: true}, exp: "2\n"},
+		{name: "IgnoreCase", cfg: config{pattern: "bar", ignoreCase: true}, exp: "2\n"},
+		{name: "Anchored", cfg: config{pattern: "^ba"}, exp: "1\n"},
+		{name: "NoMatch", cfg: config{pattern: "qux"}, exp: "0\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+
+			r := iotest.OneByteReader(strings.NewReader(input))
+			if err := run(nil, tc.cfg, r, &out, &out); err != nil {
+				t.Fatal(err)
+			}
+
+			if out.String() != tc.exp {
+				t.Errorf("Expected %q but got %q", tc.exp, out.String())
+			}
+		})
+	}
+
+	t.Run("InvalidPattern", func(t *testing.T) {
+		var out bytes.Buffer
+
+		err := run(nil, config{pattern: "("}, strings.NewReader(""), &out, &out)
+		if !errors.Is(err, ErrInvalidPattern) {
+			t.Errorf("Expected error %q, got %q instead", ErrInvalidPattern, err)
+		}
+	})
+}
@@ -0,0 +1,437 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ankitjha/counter/count"
+)
+
+const (
+	formatText = "text"
+	formatJSON = "json"
+	formatCSV  = "csv"
+)
+
+var formats = []string{formatText, formatJSON, formatCSV}
+
+// row is the result for one input, err is set when it could not be read ->
+type row struct {
+	name   string
+	lang   string
+	counts count.Result
+	err    error
+}
+
+// report is everything a run found, rendered at the end in the selected format ->
+type report struct {
+	rows  []row
+	total count.Result
+	// false when reading stdin without any names, which prints a bare number ->
+	named bool
+
+	tokenMode   bool
+	tokens      []tokenCount
+	approximate bool
+}
+
+// groupRows folds the rows into one per key, such as the extension or language, sorted
+// by key. Rows for inputs that could not be read are kept as they are ->
+func groupRows(rows []row, key func(r row) string) []row {
+	var res []row
+	groups := map[string]*row{}
+
+	for _, r := range rows {
+		if r.err != nil {
+			res = append(res, r)
+			continue
+		}
+
+		k := key(r)
+		if k == "" {
+			k = "(none)"
+		}
+
+		g, ok := groups[k]
+		if !ok {
+			g = &row{name: k, lang: r.lang}
+			groups[k] = g
+		}
+		g.counts.Add(r.counts)
+	}
+
+	for _, k := range slices.Sorted(maps.Keys(groups)) {
+		res = append(res, *groups[k])
+	}
+
+	return res
+}
+
+// err is what run returns once the report is written ->
+func (rep report) err() error {
+	for _, r := range rep.rows {
+		if r.err == nil {
+			continue
+		}
+		if !rep.named {
+			return r.err
+		}
+		return ErrReadFailed
+	}
+	return nil
+}
+
+func write(out, errOut io.Writer, rep report, format string, m modes) error {
+	switch format {
+	case formatJSON:
+		return writeJSON(out, rep, m)
+	case formatCSV:
+		return writeCSV(out, errOut, rep, m)
+	}
+	return writeText(out, errOut, rep, m)
+}
+
+// metrics returns the selected columns in the fixed lines, words, runes, bytes order,
+// averages and scores with one decimal ->
+func metrics(c count.Result, m modes) []string {
+	var cols []int
+
+	if m.lines {
+		cols = append(cols, c.Lines)
+	}
+	if m.words {
+		cols = append(cols, c.Words)
+	}
+	if m.runes {
+		cols = append(cols, c.Runes)
+	}
+	if m.bytes {
+		cols = append(cols, c.Bytes)
+	}
+	if m.code {
+		cols = append(cols, c.Code, c.Comment, c.Blank)
+	}
+	if m.match {
+		cols = append(cols, c.Matches)
+	}
+
+	var res []string
+	for _, v := range cols {
+		res = append(res, strconv.Itoa(v))
+	}
+
+	if m.stats {
+		t := c.Text
+		res = append(res,
+			strconv.Itoa(t.Sentences),
+			strconv.Itoa(t.Paragraphs),
+			decimal1(t.WordsPerSentence()),
+			decimal1(t.WordLength()),
+			strconv.Itoa(t.LongestLine),
+			decimal1(t.ReadingEase()),
+			decimal1(t.GradeLevel()),
+		)
+	}
+
+	return res
+}
+
+func metricNames(m modes) []string {
+	var names []string
+
+	if m.lines {
+		names = append(names, "lines")
+	}
+	if m.words {
+		names = append(names, "words")
+	}
+	if m.runes {
+		names = append(names, "runes")
+	}
+	if m.bytes {
+		names = append(names, "bytes")
+	}
+	if m.code {
+		names = append(names, "code", "comment", "blank")
+	}
+	if m.match {
+		names = append(names, "matches")
+	}
+	if m.stats {
+		names = append(names, "sentences", "paragraphs", "words_per_sentence", "word_length",
+			"longest_line", "reading_ease", "grade_level")
+	}
+
+	return names
+}
+
+// format lays out the selected columns like wc does ->
+func format(c count.Result, m modes) string {
+	return strings.Join(metrics(c, m), " ")
+}
+
+// warnInvalid points out inputs that were not valid in their encoding, their counts
+// take every bad sequence as one rune ->
+func warnInvalid(errOut io.Writer, rep report) {
+	for _, r := range rep.rows {
+		if r.err == nil && r.counts.Invalid > 0 {
+			_, _ = fmt.Fprintf(errOut, "%s: %d invalid byte sequences\n", r.name, r.counts.Invalid)
+		}
+	}
+}
+
+func writeText(out, errOut io.Writer, rep report, m modes) error {
+	warnInvalid(errOut, rep)
+
+	for _, r := range rep.rows {
+		// without names the error is all run returns, printing it here would repeat it ->
+		if r.err != nil {
+			if rep.named {
+				_, _ = fmt.Fprintln(errOut, r.err)
+			}
+			continue
+		}
+
+		if rep.tokenMode {
+			continue
+		}
+
+		var err error
+		if rep.named {
+			_, err = fmt.Fprintln(out, format(r.counts, m), r.name)
+		} else {
+			_, err = fmt.Fprintln(out, format(r.counts, m))
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if rep.tokenMode {
+		if rep.approximate {
+			_, _ = fmt.Fprintln(errOut, "counts are approximate, the memory cap was reached")
+		}
+
+		for _, tc := range rep.tokens {
+			if _, err := fmt.Fprintln(out, tc.count, tc.token); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if len(rep.rows) > 1 {
+		if _, err := fmt.Fprintln(out, format(rep.total, m), "total"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// jsonRow only carries the selected metrics, the others are left out ->
+type jsonRow struct {
+	Name  string `json:"name"`
+	Lines *int   `json:"lines,omitempty"`
+	Words *int   `json:"words,omitempty"`
+	Runes *int   `json:"runes,omitempty"`
+	Bytes *int   `json:"bytes,omitempty"`
+
+	Language string `json:"language,omitempty"`
+	Code     *int   `json:"code,omitempty"`
+	Comment  *int   `json:"comment,omitempty"`
+	Blank    *int   `json:"blank,omitempty"`
+
+	Matches *int       `json:"matches,omitempty"`
+	Stats   *jsonStats `json:"stats,omitempty"`
+
+	Invalid int `json:"invalid,omitempty"`
+
+	Error string `json:"error,omitempty"`
+}
+
+type jsonStats struct {
+	Sentences        int     `json:"sentences"`
+	Paragraphs       int     `json:"paragraphs"`
+	WordsPerSentence float64 `json:"words_per_sentence"`
+	WordLength       float64 `json:"word_length"`
+	LongestLine      int     `json:"longest_line"`
+	ReadingEase      float64 `json:"reading_ease"`
+	GradeLevel       float64 `json:"grade_level"`
+}
+
+type jsonToken struct {
+	Token string `json:"token"`
+	Count int    `json:"count"`
+}
+
+type jsonReport struct {
+	Files       []jsonRow   `json:"files"`
+	Total       jsonRow     `json:"total"`
+	Tokens      []jsonToken `json:"tokens,omitempty"`
+	Approximate bool        `json:"approximate,omitempty"`
+}
+
+func newJSONRow(name, lang string, c count.Result, m modes) jsonRow {
+	r := jsonRow{Name: name, Invalid: c.Invalid}
+
+	if m.lines {
+		r.Lines = &c.Lines
+	}
+	if m.words {
+		r.Words = &c.Words
+	}
+	if m.runes {
+		r.Runes = &c.Runes
+	}
+	if m.bytes {
+		r.Bytes = &c.Bytes
+	}
+	if m.code {
+		r.Language = lang
+		r.Code, r.Comment, r.Blank = &c.Code, &c.Comment, &c.Blank
+	}
+	if m.match {
+		r.Matches = &c.Matches
+	}
+	if m.stats {
+		t := c.Text
+		r.Stats = &jsonStats{
+			Sentences:        t.Sentences,
+			Paragraphs:       t.Paragraphs,
+			WordsPerSentence: round1(t.WordsPerSentence()),
+			WordLength:       round1(t.WordLength()),
+			LongestLine:      t.LongestLine,
+			ReadingEase:      round1(t.ReadingEase()),
+			GradeLevel:       round1(t.GradeLevel()),
+		}
+	}
+
+	return r
+}
+
+// round1 keeps one decimal, rounding halves away from zero. Every output goes through
+// it so text, CSV and JSON always agree ->
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
+
+// decimal1 is round1 for the text and CSV columns ->
+func decimal1(v float64) string {
+	return strconv.FormatFloat(round1(v), 'f', 1, 64)
+}
+
+func writeJSON(out io.Writer, rep report, m modes) error {
+	res := jsonReport{
+		Files:       []jsonRow{},
+		Total:       newJSONRow("total", "", rep.total, m),
+		Approximate: rep.approximate,
+	}
+
+	for _, r := range rep.rows {
+		if r.err != nil {
+			res.Files = append(res.Files, jsonRow{Name: r.name, Error: r.err.Error()})
+			continue
+		}
+		res.Files = append(res.Files, newJSONRow(r.name, r.lang, r.counts, m))
+	}
+
+	if rep.tokenMode {
+		res.Tokens = []jsonToken{}
+		for _, tc := range rep.tokens {
+			res.Tokens = append(res.Tokens, jsonToken{Token: tc.token, Count: tc.count})
+		}
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(res)
+}
+
+// writeCSV writes name, metrics and error columns, or token, count, name and error
+// columns in the token modes, where read errors follow the tokens as rows of their own ->
+func writeCSV(out, errOut io.Writer, rep report, m modes) error {
+	w := csv.NewWriter(out)
+
+	if rep.tokenMode {
+		_ = w.Write([]string{"token", "count", "name", "error"})
+		for _, tc := range rep.tokens {
+			_ = w.Write([]string{tc.token, strconv.Itoa(tc.count), "", ""})
+		}
+		for _, r := range rep.rows {
+			if r.err != nil {
+				_ = w.Write([]string{"", "", r.name, r.err.Error()})
+			}
+		}
+
+		w.Flush()
+		return w.Error()
+	}
+
+	warnInvalid(errOut, rep)
+
+	header := []string{"name"}
+	if m.code {
+		header = append(header, "language")
+	}
+	header = append(header, metricNames(m)...)
+	_ = w.Write(append(header, "error"))
+
+	record := func(name, lang string, c count.Result, err error) []string {
+		rec := []string{name}
+		if m.code {
+			rec = append(rec, lang)
+		}
+		for _, v := range metrics(c, m) {
+			if err != nil {
+				rec = append(rec, "")
+				continue
+			}
+			rec = append(rec, v)
+		}
+
+		if err != nil {
+			return append(rec, err.Error())
+		}
+		return append(rec, "")
+	}
+
+	for _, r := range rep.rows {
+		_ = w.Write(record(r.name, r.lang, r.counts, r.err))
+	}
+	_ = w.Write(record("total", "", rep.total, nil))
+
+	w.Flush()
+	return w.Error()
+}
+
+// checkReadability is the -min-ease and -max-grade gate, every row has to pass. Rows
+// without words have no scores to judge and pass both ->
+func checkReadability(rows []row, cfg config) error {
+	var failed []string
+
+	for _, r := range rows {
+		if r.err != nil || r.counts.Text.Words == 0 {
+			continue
+		}
+
+		if ease := r.counts.Text.ReadingEase(); cfg.minEase != nil && ease < *cfg.minEase {
+			failed = append(failed, fmt.Sprintf("%s reading ease %s below %s", r.name, decimal1(ease), decimal1(*cfg.minEase)))
+		}
+		if grade := r.counts.Text.GradeLevel(); cfg.maxGrade != nil && grade > *cfg.maxGrade {
+			failed = append(failed, fmt.Sprintf("%s grade level %s above %s", r.name, decimal1(grade), decimal1(*cfg.maxGrade)))
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%w: %s", ErrReadability, strings.Join(failed, ", "))
+	}
+	return nil
+}
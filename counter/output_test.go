@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ankitjha/counter/count"
+)
+
+func TestRunFormats(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.txt")
+	missing := filepath.Join(dir, "missing.txt")
+
+	if err := os.WriteFile(a, []byte("one two\nthree\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("JSON", func(t *testing.T) {
+		var out, errOut bytes.Buffer
+		cfg := config{format: formatJSON, modes: modes{lines: true, words: true}}
+
+		err := run([]string{a, missing}, cfg, strings.NewReader(""), &out, &errOut)
+		if !errors.Is(err, ErrReadFailed) {
+			t.Errorf("Expected error %q, got %q instead", ErrReadFailed, err)
+		}
+
+		var res map[string]any
+		if err := json.Unmarshal(out.Bytes(), &res); err != nil {
+			t.Fatalf("Invalid JSON %q: %v", out.String(), err)
+		}
+
+		files := res["files"].([]any)
+		if len(files) != 2 {
+			t.Fatalf("Expected 2 files but got %d", len(files))
+		}
+
+		first := files[0].(map[string]any)
+		if first["name"] != a || first["lines"] != 2.0 || first["words"] != 3.0 {
+			t.Errorf("Unexpected first row %v", first)
+		}
+		if _, ok := first["bytes"]; ok {
+			t.Errorf("Expected unselected bytes to be left out of %v", first)
+		}
+
+		second := files[1].(map[string]any)
+		if !strings.Contains(second["error"].(string), missing) {
+			t.Errorf("Expected an error for %q but got %v", missing, second)
+		}
+
+		total := res["total"].(map[string]any)
+		if total["lines"] != 2.0 || total["words"] != 3.0 {
+			t.Errorf("Unexpected total %v", total)
+		}
+	})
+
+	t.Run("JSONTokens", func(t *testing.T) {
+		var out bytes.Buffer
+		cfg := config{format: formatJSON, freq: 1}
+
+		if err := run(nil, cfg, strings.NewReader("b a b"), &out, &out); err != nil {
+			t.Fatal(err)
+		}
+
+		exp := `"tokens": [
+    {
+      "token": "b",
+      "count": 2
+    }
+  ]`
+		if !strings.Contains(out.String(), exp) {
+			t.Errorf("Expected %q in %q", exp, out.String())
+		}
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		var out, errOut bytes.Buffer
+		cfg := config{format: formatCSV, modes: modes{bytes: true, lines: true}}
+
+		err := run([]string{a, missing}, cfg, strings.NewReader(""), &out, &errOut)
+		if !errors.Is(err, ErrReadFailed) {
+			t.Errorf("Expected error %q, got %q instead", ErrReadFailed, err)
+		}
+
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		exp := []string{
+			"name,lines,bytes,error",
+			a + ",2,14,",
+			missing + ",,,open " + missing + ": no such file or directory",
+			"total,2,14,",
+		}
+
+		if len(lines) != len(exp) {
+			t.Fatalf("Expected %d lines but got %q", len(exp), out.String())
+		}
+		for i := range exp {
+			if lines[i] != exp[i] {
+				t.Errorf("Expected %q but got %q", exp[i], lines[i])
+			}
+		}
+	})
+
+	t.Run("CSVTokens", func(t *testing.T) {
+		var out, errOut bytes.Buffer
+		cfg := config{format: formatCSV, freq: 1}
+
+		err := run([]string{a, missing}, cfg, strings.NewReader(""), &out, &errOut)
+		if !errors.Is(err, ErrReadFailed) {
+			t.Errorf("Expected error %q, got %q instead", ErrReadFailed, err)
+		}
+
+		exp := "token,count,name,error\n" +
+			"one,1,,\n" +
+			",," + missing + ",open " + missing + ": no such file or directory\n"
+		if out.String() != exp {
+			t.Errorf("Expected %q but got %q", exp, out.String())
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		var out bytes.Buffer
+
+		err := run(nil, config{format: "xml"}, strings.NewReader(""), &out, &out)
+		if !errors.Is(err, ErrInvalidFormat) {
+			t.Errorf("Expected error %q, got %q instead", ErrInvalidFormat, err)
+		}
+	})
+}
+
+func TestRoundingAgrees(t *testing.T) {
+	// 13 words in 4 sentences is 3.25 words per sentence, a half ->
+	c := count.Result{Text: count.TextStats{Sentences: 4, Words: 13, Letters: 52, Syllables: 13}}
+	m := modes{stats: true}
+
+	text := metrics(c, m)[2]
+	js := strconv.FormatFloat(newJSONRow("a", "", c, m).Stats.WordsPerSentence, 'f', -1, 64)
+
+	if text != "3.3" || js != text {
+		t.Errorf("Expected text and JSON to both be %q but got %q and %q", "3.3", text, js)
+	}
+}
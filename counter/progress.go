@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// how often -progress redraws its line ->
+const progressInterval = 500 * time.Millisecond
+
+// progress redraws one line on errOut with the bytes read so far, the throughput and,
+// when the size of every input is known, how long is left ->
+type progress struct {
+	errOut io.Writer
+	start  time.Time
+	read   atomic.Int64
+	// -1 when reading something that cannot be sized, such as stdin ->
+	total int64
+
+	mu    sync.Mutex
+	width int
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newProgress(errOut io.Writer) *progress {
+	return &progress{errOut: errOut, total: -1}
+}
+
+// add is the count.Options OnRead hook, called from the counting goroutines ->
+func (p *progress) add(n int) {
+	p.read.Add(int64(n))
+}
+
+// settle sets what was read, once an input is done, to the sizes of the inputs so far,
+// as binary files skipped after a sniff or failed reads stop early ->
+func (p *progress) settle(n int64) {
+	p.read.Store(n)
+}
+
+// run starts redrawing the line until finish is called ->
+func (p *progress) run(total int64) {
+	p.total = total
+	p.start = time.Now()
+	p.stop = make(chan struct{})
+	p.done = make(chan struct{})
+
+	go func() {
+		defer close(p.done)
+
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-p.stop:
+				return
+			case <-ticker.C:
+				p.draw(time.Since(p.start))
+			}
+		}
+	}()
+}
+
+// finish stops the redraws and clears the line so nothing is left behind on errOut ->
+func (p *progress) finish() {
+	if p.stop == nil {
+		return
+	}
+	close(p.stop)
+	<-p.done
+	p.stop = nil
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.width > 0 {
+		_, _ = fmt.Fprintf(p.errOut, "\r%s\r", strings.Repeat(" ", p.width))
+		p.width = 0
+	}
+}
+
+func (p *progress) draw(elapsed time.Duration) {
+	line := renderProgress(p.read.Load(), p.total, elapsed)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// pad over whatever is left of a longer line ->
+	pad := max(p.width-len(line), 0)
+	_, _ = fmt.Fprintf(p.errOut, "\r%s%s", line, strings.Repeat(" ", pad))
+	p.width = len(line)
+}
+
+// renderProgress lays out a progress line such as "12.0 MiB read, 4.0 MiB/s, 25% ETA 0:09",
+// the percentage and ETA only when total is known ->
+func renderProgress(read, total int64, elapsed time.Duration) string {
+	var rate float64
+	if secs := elapsed.Seconds(); secs > 0 {
+		rate = float64(read) / secs
+	}
+
+	line := fmt.Sprintf("%s read, %s/s", humanBytes(float64(read)), humanBytes(rate))
+	if total < 0 {
+		return line
+	}
+
+	pct := 100
+	if total > 0 {
+		pct = int(min(read*100/total, 100))
+	}
+	line += fmt.Sprintf(", %d%%", pct)
+
+	if rate > 0 && read < total {
+		eta := time.Duration(float64(total-read) / rate * float64(time.Second))
+		line += " ETA " + clock(eta)
+	}
+
+	return line
+}
+
+// humanBytes prints n with a binary unit, bytes with no decimals ->
+func humanBytes(n float64) string {
+	units := []string{"KiB", "MiB", "GiB", "TiB"}
+
+	if n < 1024 {
+		return fmt.Sprintf("%.0f B", n)
+	}
+
+	unit := ""
+	for _, u := range units {
+		n /= 1024
+		unit = u
+		if n < 1024 {
+			break
+		}
+	}
+	return fmt.Sprintf("%.1f %s", n, unit)
+}
+
+// clock prints d as m:ss, or h:mm:ss past an hour ->
+func clock(d time.Duration) string {
+	secs := int(d.Round(time.Second).Seconds())
+	h, m, s := secs/3600, secs/60%60, secs%60
+
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%d:%02d", m, s)
+}
+
+// inputSize is the size of every input, or -1 when one of them is stdin or cannot be
+// sized up front ->
+func inputSize(inputs []input) int64 {
+	var total int64
+
+	for _, in := range inputs {
+		if in.err != nil {
+			continue
+		}
+
+		size := fileSize(in.name)
+		if size < 0 {
+			return -1
+		}
+		total += size
+	}
+
+	return total
+}
+
+// fileSize is the size of a regular file, 0 when it is gone and -1 when it is not a
+// regular file ->
+func fileSize(fname string) int64 {
+	if fname == "-" {
+		return -1
+	}
+
+	info, err := os.Stat(fname)
+	if err != nil {
+		return 0
+	}
+	if !info.Mode().IsRegular() {
+		return -1
+	}
+	return info.Size()
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderProgress(t *testing.T) {
+	testCases := []struct {
+		name    string
+		read    int64
+		total   int64
+		elapsed time.Duration
+		exp     string
+	}{
+		{name: "Unknown", read: 512, total: -1, elapsed: time.Second, exp: "512 B read, 512 B/s"},
+		{name: "ETA", read: 12 << 20, total: 48 << 20, elapsed: 3 * time.Second, exp: "12.0 MiB read, 4.0 MiB/s, 25% ETA 0:09"},
+		{name: "Done", read: 2 << 30, total: 2 << 30, elapsed: time.Second, exp: "2.0 GiB read, 2.0 GiB/s, 100%"},
+		{name: "Hours", read: 1 << 10, total: 4 << 20, elapsed: time.Second, exp: "1.0 KiB read, 1.0 KiB/s, 0% ETA 1:08:15"},
+		{name: "Started", read: 0, total: 0, elapsed: 0, exp: "0 B read, 0 B/s, 100%"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := renderProgress(tc.read, tc.total, tc.elapsed)
+			if res != tc.exp {
+				t.Errorf("Expected %q but got %q", tc.exp, res)
+			}
+		})
+	}
+}
+
+func TestRunProgress(t *testing.T) {
+	dir := t.TempDir()
+	fname := filepath.Join(dir, "big.txt")
+	if err := os.WriteFile(fname, bytes.Repeat([]byte("one two three\n"), 1<<16), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var plain, out, errOut bytes.Buffer
+	if err := run([]string{fname}, config{}, strings.NewReader(""), &plain, &errOut); err != nil {
+		t.Fatal(err)
+	}
+
+	p := newProgress(&errOut)
+	p.run(1 << 20)
+	p.add(1 << 19)
+	p.draw(time.Second)
+	p.finish()
+
+	if !strings.Contains(errOut.String(), "512.0 KiB read") {
+		t.Errorf("Expected a progress line, got %q", errOut.String())
+	}
+	if !strings.HasSuffix(errOut.String(), "\r") {
+		t.Errorf("Expected the progress line to be cleared, got %q", errOut.String())
+	}
+
+	errOut.Reset()
+	if err := run([]string{fname}, config{progress: true}, strings.NewReader(""), &out, &errOut); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != plain.String() {
+		t.Errorf("Expected %q but got %q", plain.String(), out.String())
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunStats(t *testing.T) {
+	dir := t.TempDir()
+	easy := filepath.Join(dir, "easy.md")
+	hard := filepath.Join(dir, "hard.md")
+	empty := filepath.Join(dir, "empty.md")
+
+	if err := os.WriteFile(easy, []byte("The cat sat. The dog ran.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(empty, []byte("\n---\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(hard, []byte("Comprehensive documentation necessitates considerable organizational deliberation.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Columns", func(t *testing.T) {
+		var out bytes.Buffer
+
+		if err := run([]string{easy}, config{modes: modes{stats: true}}, strings.NewReader(""), &out, &out); err != nil {
+			t.Fatal(err)
+		}
+
+		exp := "2 1 3.0 3.0 25 119.2 -2.6 " + easy + "\n"
+		if out.String() != exp {
+			t.Errorf("Expected %q but got %q", exp, out.String())
+		}
+	})
+
+	t.Run("Gate", func(t *testing.T) {
+		var out bytes.Buffer
+		minEase := 60.0
+		cfg := config{minEase: &minEase}
+
+		err := run([]string{easy, hard}, cfg, strings.NewReader(""), &out, &out)
+		if !errors.Is(err, ErrReadability) {
+			t.Fatalf("Expected error %q, got %q instead", ErrReadability, err)
+		}
+		if !strings.Contains(err.Error(), hard) || strings.Contains(err.Error(), easy) {
+			t.Errorf("Expected only %q to fail but got %q", hard, err)
+		}
+
+		if err := run([]string{easy}, cfg, strings.NewReader(""), &out, &out); err != nil {
+			t.Errorf("Expected %q to pass but got %q", easy, err)
+		}
+
+		// a file with no words has no score, both gates let it through ->
+		maxGrade := 10.0
+		for _, cfg := range []config{cfg, {maxGrade: &maxGrade}} {
+			if err := run([]string{easy, empty}, cfg, strings.NewReader(""), &out, &out); err != nil {
+				t.Errorf("Expected %q to pass but got %q", empty, err)
+			}
+		}
+	})
+}
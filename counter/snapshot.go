@@ -0,0 +1,239 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/ankitjha/counter/count"
+)
+
+// snapshot is what -snapshot saves and -compare reads back, one entry per row ->
+type snapshot struct {
+	Files []snapshotFile `json:"files"`
+}
+
+type snapshotFile struct {
+	Name  string `json:"name"`
+	Lines int    `json:"lines"`
+	Words int    `json:"words"`
+	Runes int    `json:"runes"`
+	Bytes int    `json:"bytes"`
+}
+
+// saveSnapshot writes the counts of every row that could be read to fname ->
+func saveSnapshot(fname string, rows []row) error {
+	snap := snapshot{Files: []snapshotFile{}}
+	for _, r := range rows {
+		if r.err != nil {
+			continue
+		}
+
+		c := r.counts
+		snap.Files = append(snap.Files, snapshotFile{Name: r.name, Lines: c.Lines, Words: c.Words, Runes: c.Runes, Bytes: c.Bytes})
+	}
+
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(fname, append(data, '\n'), 0644)
+}
+
+func loadSnapshot(fname string) (map[string]count.Result, error) {
+	data, err := os.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("%w: %s: %s", ErrInvalidSnapshot, fname, err)
+	}
+
+	res := make(map[string]count.Result, len(snap.Files))
+	for _, f := range snap.Files {
+		res[f.Name] = count.Result{Lines: f.Lines, Words: f.Words, Runes: f.Runes, Bytes: f.Bytes}
+	}
+	return res, nil
+}
+
+const (
+	statusNew     = "new"
+	statusDeleted = "deleted"
+	statusChanged = "changed"
+)
+
+// delta is how one row moved since the snapshot, diff being now minus then ->
+type delta struct {
+	name   string
+	status string
+	diff   count.Result
+}
+
+// compare lists the rows that are new, deleted or changed since the snapshot, sorted by
+// name, along with the sum of all the differences. Only the columns in m count as a
+// change. Rows that could not be read, and files still on disk that were not counted
+// this time, are left out rather than taken for deleted ->
+func compare(prev map[string]count.Result, rows []row, m modes) ([]delta, count.Result) {
+	cur := map[string]count.Result{}
+	unread := map[string]bool{}
+	for _, r := range rows {
+		if r.err != nil {
+			unread[r.name] = true
+			continue
+		}
+		cur[r.name] = r.counts
+	}
+
+	var deltas []delta
+	var total count.Result
+
+	names := map[string]bool{}
+	for name := range cur {
+		names[name] = true
+	}
+	for name := range prev {
+		if !unread[name] {
+			names[name] = true
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(names)) {
+		now, inNow := cur[name]
+		then, inThen := prev[name]
+
+		d := delta{name: name, status: statusChanged, diff: selected(count.Result{
+			Lines: now.Lines - then.Lines,
+			Words: now.Words - then.Words,
+			Runes: now.Runes - then.Runes,
+			Bytes: now.Bytes - then.Bytes,
+		}, m)}
+		switch {
+		case !inThen:
+			d.status = statusNew
+		case !inNow:
+			if _, err := os.Stat(name); !errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			d.status = statusDeleted
+		case d.diff == count.Result{}:
+			continue
+		}
+
+		deltas = append(deltas, d)
+		total.Add(d.diff)
+	}
+
+	return deltas, total
+}
+
+// selected keeps the lines, words, runes and bytes in m and zeroes the rest ->
+func selected(c count.Result, m modes) count.Result {
+	var res count.Result
+
+	if m.lines {
+		res.Lines = c.Lines
+	}
+	if m.words {
+		res.Words = c.Words
+	}
+	if m.runes {
+		res.Runes = c.Runes
+	}
+	if m.bytes {
+		res.Bytes = c.Bytes
+	}
+
+	return res
+}
+
+// signed lays out the selected columns of a difference with their sign ->
+func signed(c count.Result, m modes) string {
+	cols := metrics(c, m)
+	for i, v := range cols {
+		if !strings.HasPrefix(v, "-") {
+			cols[i] = "+" + v
+		}
+	}
+
+	return strings.Join(cols, " ")
+}
+
+type jsonDelta struct {
+	jsonRow
+	Status string `json:"status,omitempty"`
+}
+
+type jsonDeltas struct {
+	Files []jsonDelta `json:"files"`
+	Total jsonRow     `json:"total"`
+}
+
+// writeDeltas prints what compare found in the selected format, unchanged rows are left
+// out. Rows that could not be read follow the deltas with their error in JSON and CSV,
+// and go to errOut as text ->
+func writeDeltas(out, errOut io.Writer, rep report, deltas []delta, total count.Result, format string, m modes) error {
+	var failed []row
+	for _, r := range rep.rows {
+		if r.err != nil {
+			failed = append(failed, r)
+		}
+	}
+
+	switch format {
+	case formatJSON:
+		res := jsonDeltas{Files: []jsonDelta{}, Total: newJSONRow("total", "", total, m)}
+		for _, d := range deltas {
+			res.Files = append(res.Files, jsonDelta{jsonRow: newJSONRow(d.name, "", d.diff, m), Status: d.status})
+		}
+		for _, r := range failed {
+			res.Files = append(res.Files, jsonDelta{jsonRow: jsonRow{Name: r.name, Error: r.err.Error()}})
+		}
+
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(res)
+
+	case formatCSV:
+		w := csv.NewWriter(out)
+		names := metricNames(m)
+		_ = w.Write(append(append([]string{"name", "status"}, names...), "error"))
+		for _, d := range deltas {
+			_ = w.Write(append(append([]string{d.name, d.status}, metrics(d.diff, m)...), ""))
+		}
+		for _, r := range failed {
+			rec := append([]string{r.name, ""}, make([]string, len(names))...)
+			_ = w.Write(append(rec, r.err.Error()))
+		}
+		_ = w.Write(append(append([]string{"total", ""}, metrics(total, m)...), ""))
+
+		w.Flush()
+		return w.Error()
+	}
+
+	for _, r := range failed {
+		_, _ = fmt.Fprintln(errOut, r.err)
+	}
+
+	for _, d := range deltas {
+		line := signed(d.diff, m) + " " + d.name
+		if d.status != statusChanged {
+			line += " (" + d.status + ")"
+		}
+		if _, err := fmt.Fprintln(out, line); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintln(out, signed(total, m), "total")
+	return err
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunSnapshotCompare(t *testing.T) {
+	dir := t.TempDir()
+	snap := filepath.Join(dir, "snap.json")
+	a := filepath.Join(dir, "a.txt")
+	b := filepath.Join(dir, "b.txt")
+	c := filepath.Join(dir, "c.txt")
+	same := filepath.Join(dir, "same.txt")
+
+	write := func(fname, data string) {
+		t.Helper()
+		if err := os.WriteFile(fname, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(a, "one two\nthree\n")
+	write(b, "gone soon\n")
+	write(same, "as it was\n")
+
+	var out bytes.Buffer
+	if err := run([]string{a, b, same}, config{snapshot: snap}, strings.NewReader(""), &out, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "3 "+a+"\n2 "+b+"\n3 "+same+"\n8 total\n" {
+		t.Errorf("Expected the usual output along with the snapshot but got %q", out.String())
+	}
+
+	write(a, "one two\nthree four five\nsix\n")
+	write(c, "brand new\n")
+
+	t.Run("Subset", func(t *testing.T) {
+		var out bytes.Buffer
+
+		// b.txt and same.txt are still there, only not counted this time ->
+		if err := run([]string{a}, config{compare: snap}, strings.NewReader(""), &out, &out); err != nil {
+			t.Fatal(err)
+		}
+
+		exp := "+1 +3 " + a + "\n+1 +3 total\n"
+		if out.String() != exp {
+			t.Errorf("Expected %q but got %q", exp, out.String())
+		}
+	})
+
+	if err := os.Remove(b); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Text", func(t *testing.T) {
+		var out bytes.Buffer
+
+		err := run([]string{a, c, same}, config{compare: snap}, strings.NewReader(""), &out, &out)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		exp := "+1 +3 " + a + "\n" +
+			"-1 -2 " + b + " (deleted)\n" +
+			"+1 +2 " + c + " (new)\n" +
+			"+1 +3 total\n"
+		if out.String() != exp {
+			t.Errorf("Expected %q but got %q", exp, out.String())
+		}
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		var out bytes.Buffer
+		cfg := config{compare: snap, format: formatJSON, modes: modes{bytes: true}}
+
+		if err := run([]string{a, c, same}, cfg, strings.NewReader(""), &out, &out); err != nil {
+			t.Fatal(err)
+		}
+
+		var res struct {
+			Files []struct {
+				Name   string `json:"name"`
+				Status string `json:"status"`
+				Bytes  int    `json:"bytes"`
+			} `json:"files"`
+		}
+		if err := json.Unmarshal(out.Bytes(), &res); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(res.Files) != 3 || res.Files[0].Status != statusChanged || res.Files[0].Bytes != 14 {
+			t.Errorf("Unexpected deltas %+v", res.Files)
+		}
+	})
+
+	t.Run("UnreadIsNotDeleted", func(t *testing.T) {
+		var out, errOut bytes.Buffer
+
+		err := run([]string{a, b, same}, config{compare: snap}, strings.NewReader(""), &out, &errOut)
+		if !errors.Is(err, ErrReadFailed) {
+			t.Errorf("Expected error %q, got %q instead", ErrReadFailed, err)
+		}
+		if strings.Contains(out.String(), "deleted") {
+			t.Errorf("Expected %q not to show as deleted but got %q", b, out.String())
+		}
+	})
+
+	t.Run("ErrorRows", func(t *testing.T) {
+		var out, errOut bytes.Buffer
+
+		err := run([]string{a, b, same}, config{compare: snap, format: formatCSV}, strings.NewReader(""), &out, &errOut)
+		if !errors.Is(err, ErrReadFailed) {
+			t.Errorf("Expected error %q, got %q instead", ErrReadFailed, err)
+		}
+
+		exp := "name,status,lines,words,error\n" +
+			a + ",changed,1,3,\n" +
+			b + ",,,,open " + b + ": no such file or directory\n" +
+			"total,,1,3,\n"
+		if out.String() != exp {
+			t.Errorf("Expected %q but got %q", exp, out.String())
+		}
+
+		out.Reset()
+		_ = run([]string{a, b, same}, config{compare: snap, format: formatJSON}, strings.NewReader(""), &out, &errOut)
+
+		var res struct {
+			Files []struct {
+				Name  string `json:"name"`
+				Error string `json:"error"`
+			} `json:"files"`
+		}
+		if err := json.Unmarshal(out.Bytes(), &res); err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Files) != 2 || res.Files[1].Name != b || !strings.Contains(res.Files[1].Error, b) {
+			t.Errorf("Expected an error for %q but got %+v", b, res.Files)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		var out bytes.Buffer
+
+		if err := run([]string{a}, config{compare: snap, modes: modes{code: true}}, strings.NewReader(""), &out, &out); !errors.Is(err, ErrInvalidCompare) {
+			t.Errorf("Expected error %q, got %q instead", ErrInvalidCompare, err)
+		}
+
+		write(c, "not json")
+		if err := run([]string{a}, config{compare: c}, strings.NewReader(""), &out, &out); !errors.Is(err, ErrInvalidSnapshot) {
+			t.Errorf("Expected error %q, got %q instead", ErrInvalidSnapshot, err)
+		}
+	})
+}
+
+func TestCompareSelectedColumns(t *testing.T) {
+	dir := t.TempDir()
+	snap := filepath.Join(dir, "snap.json")
+	fname := filepath.Join(dir, "b2.txt")
+
+	if err := os.WriteFile(fname, []byte("x y\nz"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := run([]string{fname}, config{snapshot: snap}, strings.NewReader(""), &out, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	// only the bytes change, which are not among the columns compared ->
+	if err := os.WriteFile(fname, []byte("x y\nzmore\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+	if err := run([]string{fname}, config{compare: snap}, strings.NewReader(""), &out, &out); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.String(), fname) {
+		t.Errorf("Expected %q not to show as changed but got %q", fname, out.String())
+	}
+
+	out.Reset()
+	cfg := config{compare: snap, modes: modes{bytes: true}}
+	if err := run([]string{fname}, cfg, strings.NewReader(""), &out, &out); err != nil {
+		t.Fatal(err)
+	}
+	if exp := "+5 " + fname + "\n+5 total\n"; out.String() != exp {
+		t.Errorf("Expected %q but got %q", exp, out.String())
+	}
+}
+
+func TestRunSnapshotRolling(t *testing.T) {
+	dir := t.TempDir()
+	snap := filepath.Join(dir, "snap.json")
+	fname := filepath.Join(dir, "f.txt")
+
+	if err := os.WriteFile(fname, []byte("one two\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := run([]string{fname}, config{snapshot: snap}, strings.NewReader(""), &out, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	// the same file is compared against, then replaced for the next run ->
+	steps := []struct {
+		data string
+		exp  string
+	}{
+		{data: "one two\nthree four five\n", exp: "+1 +3 " + fname + "\n+1 +3 total\n"},
+		{data: "one two\nthree four five\nsix\n", exp: "+1 +1 " + fname + "\n+1 +1 total\n"},
+	}
+
+	cfg := config{compare: snap, snapshot: snap, modes: modes{lines: true, words: true}}
+	for _, step := range steps {
+		if err := os.WriteFile(fname, []byte(step.data), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		out.Reset()
+		if err := run([]string{fname}, cfg, strings.NewReader(""), &out, &out); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != step.exp {
+			t.Errorf("Expected %q but got %q", step.exp, out.String())
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bufio"
+	"container/heap"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// tokenCount is one row of the -freq and -ngram reports ->
+type tokenCount struct {
+	token string
+	count int
+}
+
+// tokenStats turns the tokens count hands out into word or n-gram frequencies ->
+type tokenStats struct {
+	n      int
+	fold   bool
+	minLen int
+	stop   map[string]bool
+
+	// the last n-1 tokens, n-grams never run across two inputs ->
+	window []string
+	tally  *tally
+}
+
+func newTokenStats(n int, cfg config) (*tokenStats, error) {
+	s := &tokenStats{
+		n:      n,
+		fold:   cfg.fold,
+		minLen: cfg.minLen,
+		stop:   map[string]bool{},
+		tally:  newTally(cfg.maxKeys),
+	}
+
+	if cfg.stopFile == "" {
+		return s, nil
+	}
+
+	file, err := os.Open(cfg.stopFile)
+	if err != nil {
+		return nil, fmt.Errorf("cant read stop words: %w", err)
+	}
+	defer file.Close()
+
+	// stop words are whitespace separated, usually one per line ->
+	scanner := bufio.NewScanner(file)
+	scanner.Split(bufio.ScanWords)
+	for scanner.Scan() {
+		s.stop[s.normalize(scanner.Text())] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cant read stop words: %w", err)
+	}
+
+	return s, nil
+}
+
+func (s *tokenStats) normalize(tok string) string {
+	if s.fold {
+		return strings.ToLower(tok)
+	}
+	return tok
+}
+
+func (s *tokenStats) add(tok []byte) {
+	if utf8.RuneCount(tok) < s.minLen {
+		return
+	}
+
+	t := s.normalize(string(tok))
+	if s.stop[t] {
+		return
+	}
+
+	if s.n <= 1 {
+		s.tally.add(t)
+		return
+	}
+
+	s.window = append(s.window, t)
+	if len(s.window) < s.n {
+		return
+	}
+
+	s.tally.add(strings.Join(s.window, " "))
+	s.window = slices.Delete(s.window, 0, 1)
+}
+
+// reset forgets the current window, called between inputs ->
+func (s *tokenStats) reset() {
+	s.window = s.window[:0]
+}
+
+// tally counts keys exactly until it holds maxKeys of them, then falls back to the
+// Space-Saving heavy hitters sketch so memory stays capped. Counts of the sketch can
+// only be too high, by at most the count of the key that got evicted for them ->
+type tally struct {
+	maxKeys int
+	exact   map[string]int
+	sketch  *spaceSaving
+}
+
+func newTally(maxKeys int) *tally {
+	return &tally{maxKeys: maxKeys, exact: map[string]int{}}
+}
+
+func (t *tally) add(key string) {
+	if t.sketch != nil {
+		t.sketch.add(key)
+		return
+	}
+
+	t.exact[key]++
+	if t.maxKeys > 0 && len(t.exact) > t.maxKeys {
+		t.sketch = newSpaceSaving(t.maxKeys, t.exact)
+		t.exact = nil
+	}
+}
+
+// approximate reports whether the memory cap was hit and counts are estimates ->
+func (t *tally) approximate() bool {
+	return t.sketch != nil
+}
+
+// top returns the n most frequent keys, ties broken alphabetically so output is stable ->
+func (t *tally) top(n int) []tokenCount {
+	var res []tokenCount
+	if t.sketch != nil {
+		res = t.sketch.counts()
+	} else {
+		res = make([]tokenCount, 0, len(t.exact))
+		for k, c := range t.exact {
+			res = append(res, tokenCount{token: k, count: c})
+		}
+	}
+
+	slices.SortFunc(res, func(a, b tokenCount) int {
+		if a.count != b.count {
+			return b.count - a.count
+		}
+		return strings.Compare(a.token, b.token)
+	})
+
+	if len(res) > n {
+		res = res[:n]
+	}
+	return res
+}
+
+// spaceSaving keeps a fixed number of counters, a new key takes over the smallest one ->
+type spaceSaving struct {
+	size  int
+	index map[string]*ssEntry
+	heap  ssHeap
+}
+
+type ssEntry struct {
+	key   string
+	count int
+	pos   int
+}
+
+// newSpaceSaving seeds the sketch with the largest counts of an exact tally ->
+func newSpaceSaving(size int, seed map[string]int) *spaceSaving {
+	s := &spaceSaving{size: size, index: make(map[string]*ssEntry, size)}
+
+	entries := make([]*ssEntry, 0, len(seed))
+	for k, c := range seed {
+		entries = append(entries, &ssEntry{key: k, count: c})
+	}
+	slices.SortFunc(entries, func(a, b *ssEntry) int {
+		return b.count - a.count
+	})
+
+	for _, e := range entries[:min(size, len(entries))] {
+		s.index[e.key] = e
+		heap.Push(&s.heap, e)
+	}
+
+	return s
+}
+
+func (s *spaceSaving) add(key string) {
+	if e, ok := s.index[key]; ok {
+		e.count++
+		heap.Fix(&s.heap, e.pos)
+		return
+	}
+
+	if len(s.heap) < s.size {
+		e := &ssEntry{key: key, count: 1}
+		s.index[key] = e
+		heap.Push(&s.heap, e)
+		return
+	}
+
+	// evict the smallest counter, the new key inherits its count ->
+	e := s.heap[0]
+	delete(s.index, e.key)
+	e.key = key
+	e.count++
+	s.index[key] = e
+	heap.Fix(&s.heap, 0)
+}
+
+func (s *spaceSaving) counts() []tokenCount {
+	res := make([]tokenCount, 0, len(s.heap))
+	for _, e := range s.heap {
+		res = append(res, tokenCount{token: e.key, count: e.count})
+	}
+	return res
+}
+
+// ssHeap is a min-heap on count for container/heap ->
+type ssHeap []*ssEntry
+
+func (h ssHeap) Len() int           { return len(h) }
+func (h ssHeap) Less(i, j int) bool { return h[i].count < h[j].count }
+
+func (h ssHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].pos = i
+	h[j].pos = j
+}
+
+func (h *ssHeap) Push(x any) {
+	e := x.(*ssEntry)
+	e.pos = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *ssHeap) Pop() any {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
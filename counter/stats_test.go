@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ankitjha/counter/count"
+)
+
+func TestFreq(t *testing.T) {
+	input := "The cat and the dog. The END, the end!\ncan't stop; won't stop\n"
+
+	dir := t.TempDir()
+	stopFile := filepath.Join(dir, "stop.txt")
+	if err := os.WriteFile(stopFile, []byte("the\nand\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name string
+		cfg  config
+		exp  string
+	}{
+		{name: "Plain", cfg: config{freq: 3},
+			exp: "2 The\n2 the\n1 END,\n",
+		},
+		{name: "Fold", cfg: config{freq: 2, fold: true},
+			exp: "4 the\n1 and\n",
+		},
+		{name: "StopWords", cfg: config{freq: 3, fold: true, stopFile: stopFile},
+			exp: "1 can't\n1 cat\n1 dog.\n",
+		},
+		{name: "MinLen", cfg: config{freq: 2, minLen: 5},
+			exp: "1 can't\n1 stop;\n",
+		},
+		{name: "UnicodeWords", cfg: config{freq: 3, fold: true, unicodeWords: true},
+			exp: "4 the\n2 end\n2 stop\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := run(nil, tc.cfg, strings.NewReader(input), &out, &out); err != nil {
+				t.Fatal(err)
+			}
+
+			if out.String() != tc.exp {
+				t.Errorf("Expected %q but got %q", tc.exp, out.String())
+			}
+		})
+	}
+}
+
+func TestFreqMatchesCount(t *testing.T) {
+	input := "Don't panic: the answer is 42, or 3.14 — maybe e.g. foo_bar.\n日本語のテキスト"
+
+	for _, unicodeWords := range []bool{false, true} {
+		cfg := config{unicodeWords: unicodeWords}
+		s, err := newTokenStats(1, cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		counter, err := count.New(counterOptions(cfg, modes{}, s.add))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		c, err := counter.Count(strings.NewReader(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the histogram holds exactly the words that were counted ->
+		total := 0
+		for _, tc := range s.tally.top(len(s.tally.exact)) {
+			total += tc.count
+		}
+		if total != c.Words {
+			t.Errorf("Unicode words %t: expected %d tokens but got %d", unicodeWords, c.Words, total)
+		}
+	}
+}
+
+func TestFreqErrors(t *testing.T) {
+	var out bytes.Buffer
+
+	err := run(nil, config{freq: -1}, strings.NewReader(""), &out, &out)
+	if !errors.Is(err, ErrInvalidFreq) {
+		t.Errorf("Expected error %q, got %q instead", ErrInvalidFreq, err)
+	}
+
+	err = run(nil, config{ngram: -1}, strings.NewReader(""), &out, &out)
+	if !errors.Is(err, ErrInvalidNgram) {
+		t.Errorf("Expected error %q, got %q instead", ErrInvalidNgram, err)
+	}
+
+	err = run(nil, config{freq: 1, stopFile: "./testdata/missing.txt"}, strings.NewReader(""), &out, &out)
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected error %q, got %q instead", os.ErrNotExist, err)
+	}
+}
+
+func TestNgram(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.txt")
+	b := filepath.Join(dir, "b.txt")
+
+	if err := os.WriteFile(a, []byte("to be or not to be\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(b, []byte("Be quick to be\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name  string
+		files []string
+		cfg   config
+		exp   string
+	}{
+		{name: "Bigrams", files: []string{a}, cfg: config{ngram: 2, top: 2},
+			exp: "2 to be\n1 be or\n",
+		},
+		{name: "Trigrams", files: []string{a}, cfg: config{ngram: 3, top: 10},
+			exp: "1 be or not\n1 not to be\n1 or not to\n1 to be or\n",
+		},
+		// the last word of a.txt and the first of b.txt never make a pair ->
+		{name: "AcrossFiles", files: []string{a, b}, cfg: config{ngram: 2, top: 3, fold: true},
+			exp: "3 to be\n1 be or\n1 be quick\n",
+		},
+		{name: "FreqIsUnigrams", files: []string{a}, cfg: config{ngram: 1, top: 2},
+			exp: "2 be\n2 to\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			if err := run(tc.files, tc.cfg, strings.NewReader(""), &out, &errOut); err != nil {
+				t.Fatal(err)
+			}
+
+			if out.String() != tc.exp {
+				t.Errorf("Expected %q but got %q", tc.exp, out.String())
+			}
+		})
+	}
+}
+
+func TestTallyMemoryCap(t *testing.T) {
+	tl := newTally(10)
+
+	// a and b are heavy hitters among a long tail of keys seen once ->
+	for i := 0; i < 1000; i++ {
+		tl.add("a")
+		if i%2 == 0 {
+			tl.add("b")
+		}
+		tl.add(fmt.Sprint("x", i))
+	}
+
+	if !tl.approximate() {
+		t.Fatal("Expected the tally to fall back to the sketch")
+	}
+
+	top := tl.top(2)
+	if len(top) != 2 || top[0].token != "a" || top[1].token != "b" {
+		t.Fatalf("Expected a and b on top but got %v", top)
+	}
+
+	// Space-Saving never undercounts ->
+	if top[0].count < 1000 || top[1].count < 500 {
+		t.Errorf("Expected counts of at least 1000 and 500 but got %v", top)
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// globs collects a repeatable flag such as -include ->
+type globs []string
+
+func (g *globs) String() string {
+	return strings.Join(*g, ",")
+}
+
+func (g *globs) Set(v string) error {
+	*g = append(*g, v)
+	return nil
+}
+
+// input is one file to count, walked is set for files found by -r rather than named ->
+type input struct {
+	name   string
+	walked bool
+	err    error
+}
+
+// expand turns the names given on the command line into the files to count, walking
+// directories when -r is set ->
+func expand(filenames []string, cfg config) []input {
+	var inputs []input
+
+	for _, fname := range filenames {
+		info, err := os.Stat(fname)
+		if !cfg.recursive || fname == "-" || err != nil || !info.IsDir() {
+			inputs = append(inputs, input{name: fname})
+			continue
+		}
+
+		inputs = append(inputs, walk(fname, cfg)...)
+	}
+
+	return inputs
+}
+
+// walk lists the regular files under root that pass the include and exclude globs and
+// are not ignored by a .gitignore on the way ->
+func walk(root string, cfg config) []input {
+	var inputs []input
+	ignores := map[string][]ignoreRule{}
+
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			inputs = append(inputs, input{name: p, err: err})
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if rel != "." && (d.Name() == ".git" || matchAny(cfg.exclude, rel) || ignored(ignores, rel, true)) {
+				return filepath.SkipDir
+			}
+
+			if err := loadIgnore(ignores, p, rel); err != nil {
+				inputs = append(inputs, input{name: filepath.Join(p, ".gitignore"), err: err})
+			}
+			return nil
+		}
+
+		if !d.Type().IsRegular() || matchAny(cfg.exclude, rel) || ignored(ignores, rel, false) {
+			return nil
+		}
+		if len(cfg.include) > 0 && !matchAny(cfg.include, rel) {
+			return nil
+		}
+
+		inputs = append(inputs, input{name: p, walked: true})
+		return nil
+	})
+	if err != nil {
+		inputs = append(inputs, input{name: root, err: err})
+	}
+
+	return inputs
+}
+
+// matchAny reports whether rel matches any of the patterns, a pattern without a slash
+// is matched against every element of the path ->
+func matchAny(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		if !strings.Contains(pattern, "/") {
+			for _, elem := range strings.Split(rel, "/") {
+				if ok, _ := path.Match(pattern, elem); ok {
+					return true
+				}
+			}
+			continue
+		}
+
+		if matchGlob(strings.TrimPrefix(pattern, "./"), rel) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchGlob matches a slash separated path against a pattern where ** stands for any
+// number of path elements, including none ->
+func matchGlob(pattern, name string) bool {
+	return matchElems(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchElems(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchElems(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+
+		pattern, name = pattern[1:], name[1:]
+	}
+
+	return len(name) == 0
+}
+
+// ignoreRule is one line of a .gitignore file ->
+type ignoreRule struct {
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// loadIgnore reads the .gitignore of dir, if there is one, and files its rules under rel ->
+func loadIgnore(ignores map[string][]ignoreRule, dir, rel string) error {
+	file, err := os.Open(filepath.Join(dir, ".gitignore"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		r := ignoreRule{}
+		if strings.HasPrefix(line, "!") {
+			r.negate = true
+			line = line[1:]
+		}
+		line = strings.TrimPrefix(line, "\\")
+
+		if strings.HasSuffix(line, "/") {
+			r.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+
+		// a slash anywhere but at the end ties the pattern to this directory ->
+		if strings.Contains(line, "/") {
+			r.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+
+		r.pattern = line
+		ignores[rel] = append(ignores[rel], r)
+	}
+
+	return scanner.Err()
+}
+
+// ignored reports whether rel is ignored by the .gitignore files of its parent
+// directories, deeper files and later lines win ->
+func ignored(ignores map[string][]ignoreRule, rel string, isDir bool) bool {
+	elems := strings.Split(rel, "/")
+	res := false
+
+	for i := 0; i < len(elems); i++ {
+		base := "."
+		if i > 0 {
+			base = strings.Join(elems[:i], "/")
+		}
+
+		sub := strings.Join(elems[i:], "/")
+		for _, r := range ignores[base] {
+			if r.dirOnly && !isDir {
+				continue
+			}
+
+			var ok bool
+			if r.anchored {
+				ok = matchGlob(r.pattern, sub)
+			} else {
+				ok, _ = path.Match(r.pattern, elems[len(elems)-1])
+			}
+
+			if ok {
+				res = !r.negate
+			}
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMatchGlob(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		name    string
+		exp     bool
+	}{
+		{pattern: "vendor/**", name: "vendor/lib/x.go", exp: true},
+		{pattern: "vendor/**", name: "vendor", exp: true},
+		{pattern: "vendor/**", name: "src/vendor/x.go", exp: false},
+		{pattern: "**/testdata/*.md", name: "a/b/testdata/x.md", exp: true},
+		{pattern: "**/testdata/*.md", name: "testdata/x.md", exp: true},
+		{pattern: "src/*.go", name: "src/sub/x.go", exp: false},
+		{pattern: "src/**/*.go", name: "src/sub/x.go", exp: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.pattern+"_"+tc.name, func(t *testing.T) {
+			if res := matchGlob(tc.pattern, tc.name); res != tc.exp {
+				t.Errorf("Expected %t but got %t", tc.exp, res)
+			}
+		})
+	}
+}
+
+func TestRunRecursive(t *testing.T) {
+	root := t.TempDir()
+
+	files := map[string]string{
+		".gitignore":        "# logs\n*.log\nbuild/\n!keep.log\n",
+		"a.go":              "package a\n",
+		"b.txt":             "hello\n",
+		"debug.log":         "ignored\n",
+		"keep.log":          "kept\n",
+		"bin.dat":           "\x00\x01\x02",
+		"build/out.go":      "ignored\n",
+		"vendor/lib/x.go":   "excluded\n",
+		"sub/.gitignore":    "/local.go\n",
+		"sub/local.go":      "ignored\n",
+		"sub/c.go":          "x\ny\n",
+		"sub/deep/local.go": "package deep\n",
+		".git/config":       "[core]\n",
+	}
+	for name, content := range files {
+		fname := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fname), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fname, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCases := []struct {
+		name string
+		cfg  config
+		exp  []string
+	}{
+		{name: "IncludeExclude",
+			cfg: config{recursive: true, modes: modes{lines: true},
+				include: globs{"*.go"}, exclude: globs{"vendor/**"}},
+			exp: []string{
+				"1 " + filepath.Join(root, "a.go"),
+				"2 " + filepath.Join(root, "sub", "c.go"),
+				"1 " + filepath.Join(root, "sub", "deep", "local.go"),
+				"4 total",
+			},
+		},
+		{name: "ByExt",
+			cfg: config{recursive: true, modes: modes{lines: true},
+				exclude: globs{"vendor", ".gitignore"}, byExt: true},
+			exp: []string{
+				"4 .go",
+				"1 .log",
+				"1 .txt",
+				"6 total",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			if err := run([]string{root}, tc.cfg, strings.NewReader(""), &out, &errOut); err != nil {
+				t.Fatalf("Unexpected error %q: %s", err, errOut.String())
+			}
+
+			exp := strings.Join(tc.exp, "\n") + "\n"
+			if out.String() != exp {
+				t.Errorf("Expected %q but got %q", exp, out.String())
+			}
+		})
+	}
+}
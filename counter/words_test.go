@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUnicodeWordsGolden(t *testing.T) {
+	fixtures, err := filepath.Glob("./testdata/unicode/*.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixtures) == 0 {
+		t.Fatal("no fixtures found")
+	}
+
+	for _, fname := range fixtures {
+		t.Run(filepath.Base(fname), func(t *testing.T) {
+			file, err := os.Open(fname)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+
+			var out bytes.Buffer
+			cfg := config{modes: modes{words: true}, unicodeWords: true}
+			if err := run(nil, cfg, file, &out, &out); err != nil {
+				t.Fatal(err)
+			}
+
+			expected, err := os.ReadFile(fname + ".golden")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(expected, out.Bytes()) {
+				t.Errorf("Expected %q but got %q", expected, out.String())
+			}
+		})
+	}
+}
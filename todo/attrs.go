@@ -0,0 +1,85 @@
+package todo
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DateFormat is how due dates are written, in the task text as due:2026-11-01 and on
+// the command line
+const DateFormat = "2006-01-02"
+
+// Priorities from most to least urgent, no priority sorts after all of them
+var Priorities = []string{"A", "B", "C", "D", "E"}
+
+// ParsePriority checks a priority, lower case letters are accepted and "" means none
+func ParsePriority(p string) (string, error) {
+	p = strings.ToUpper(p)
+	if p != "" && !slices.Contains(Priorities, p) {
+		return "", fmt.Errorf("invalid priority %q, expected one of %s", p, strings.Join(Priorities, ", "))
+	}
+	return p, nil
+}
+
+// ParseDue reads a due date in DateFormat, as midnight in the local time zone
+func ParseDue(s string) (time.Time, error) {
+	due, err := time.ParseInLocation(DateFormat, s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid due date %q, expected YYYY-MM-DD", s)
+	}
+	return due, nil
+}
+
+// Overdue reports whether the task is still open after its due day has ended
+func (t Todo) Overdue(now time.Time) bool {
+	return !t.Done && !t.Due.IsZero() && !now.Before(t.Due.AddDate(0, 0, 1))
+}
+
+// parseTask reads todo.txt style attributes out of the text, a leading "(A)" sets the
+// priority and a due:YYYY-MM-DD word the due date, both are taken out of the text.
+// +project and @context words stay in the text and are collected into the tags
+func parseTask(text string) (task, priority string, due time.Time, projects, contexts []string) {
+	words := strings.Fields(text)
+
+	if len(words) > 0 && len(words[0]) == 3 && words[0][0] == '(' && words[0][2] == ')' {
+		if p := words[0][1:2]; slices.Contains(Priorities, p) {
+			priority, words = p, words[1:]
+		}
+	}
+
+	var kept []string
+	for _, w := range words {
+		if s, ok := strings.CutPrefix(w, "due:"); ok {
+			if d, err := ParseDue(s); err == nil {
+				due = d
+				continue
+			}
+		}
+
+		switch {
+		case len(w) > 1 && w[0] == '+' && !slices.Contains(projects, w[1:]):
+			projects = append(projects, w[1:])
+		case len(w) > 1 && w[0] == '@' && !slices.Contains(contexts, w[1:]):
+			contexts = append(contexts, w[1:])
+		}
+		kept = append(kept, w)
+	}
+
+	return strings.Join(kept, " "), priority, due, projects, contexts
+}
+
+// setText replaces the text of t, a priority or due date in the new text replaces the
+// old one, otherwise they are kept
+func (t *Todo) setText(text string) {
+	task, priority, due, projects, contexts := parseTask(text)
+
+	t.Task, t.Projects, t.Contexts = task, projects, contexts
+	if priority != "" {
+		t.Priority = priority
+	}
+	if !due.IsZero() {
+		t.Due = due
+	}
+}
@@ -0,0 +1,182 @@
+package todo_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ankitjha420/todo"
+)
+
+func TestAddAttributes(t *testing.T) {
+	testCases := []struct {
+		name     string
+		text     string
+		task     string
+		priority string
+		due      string
+		projects []string
+		contexts []string
+	}{
+		{name: "Plain", text: "new task", task: "new task"},
+		{name: "Priority", text: "(B) new task", task: "new task", priority: "B"},
+		{name: "NotPriority", text: "(F) new task", task: "(F) new task"},
+		{name: "PriorityNotFirst", text: "new (A) task", task: "new (A) task"},
+		{name: "Due", text: "pay rent due:2026-11-01", task: "pay rent", due: "2026-11-01"},
+		{name: "InvalidDue", text: "pay rent due:soon", task: "pay rent due:soon"},
+		{
+			name:     "Tags",
+			text:     "(A) call mom +family @phone +family due:2026-11-01 @home",
+			task:     "call mom +family @phone +family @home",
+			priority: "A",
+			due:      "2026-11-01",
+			projects: []string{"family"},
+			contexts: []string{"phone", "home"},
+		},
+		{name: "LoneSigns", text: "1 + 1 @", task: "1 + 1 @"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			list := todo.List{}
+			list.Add(tc.text)
+			item := list[0]
+
+			if item.Task != tc.task {
+				t.Errorf("expected %q but got %q", tc.task, item.Task)
+			}
+			if item.Priority != tc.priority {
+				t.Errorf("expected priority %q but got %q", tc.priority, item.Priority)
+			}
+
+			due := ""
+			if !item.Due.IsZero() {
+				due = item.Due.Format(todo.DateFormat)
+			}
+			if due != tc.due {
+				t.Errorf("expected due %q but got %q", tc.due, due)
+			}
+
+			if !slices.Equal(item.Projects, tc.projects) {
+				t.Errorf("expected projects %v but got %v", tc.projects, item.Projects)
+			}
+			if !slices.Equal(item.Contexts, tc.contexts) {
+				t.Errorf("expected contexts %v but got %v", tc.contexts, item.Contexts)
+			}
+		})
+	}
+}
+
+func TestEditAttributes(t *testing.T) {
+	list := todo.List{}
+	list.Add("(A) old task +work due:2026-11-01")
+
+	if err := list.Edit(1, "new task @home"); err != nil {
+		t.Fatal(err)
+	}
+
+	item := list[0]
+	if item.Priority != "A" || item.Due.Format(todo.DateFormat) != "2026-11-01" {
+		t.Errorf("expected priority and due date to be kept but got %q and %v", item.Priority, item.Due)
+	}
+	if len(item.Projects) != 0 || !slices.Equal(item.Contexts, []string{"home"}) {
+		t.Errorf("expected tags of the new text but got %v and %v", item.Projects, item.Contexts)
+	}
+
+	if err := list.Edit(1, "(C) new task"); err != nil {
+		t.Fatal(err)
+	}
+	if list[0].Priority != "C" {
+		t.Errorf("expected priority C but got %q", list[0].Priority)
+	}
+
+	if err := list.Edit(1, "(B) due:2026-12-01"); err == nil {
+		t.Error("expected an error for text with no task left")
+	}
+	if list[0].Task != "new task" || list[0].Priority != "C" {
+		t.Errorf("expected the item to be unchanged but got %+v", list[0])
+	}
+}
+
+func TestSetPriorityDue(t *testing.T) {
+	list := todo.List{}
+	list.Add("new task")
+
+	if err := list.SetPriority(1, "d"); err != nil {
+		t.Fatal(err)
+	}
+	if list[0].Priority != "D" {
+		t.Errorf("expected priority D but got %q", list[0].Priority)
+	}
+	if err := list.SetPriority(1, "F"); err == nil {
+		t.Error("expected an error for priority F")
+	}
+	if err := list.SetPriority(2, "A"); err == nil {
+		t.Error("expected an error for a missing item")
+	}
+
+	due, err := todo.ParseDue("2026-11-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := list.SetDue(1, due); err != nil {
+		t.Fatal(err)
+	}
+	if !list[0].Due.Equal(due) {
+		t.Errorf("expected due %v but got %v", due, list[0].Due)
+	}
+
+	if _, err := todo.ParseDue("11/01/2026"); err == nil {
+		t.Error("expected an error for an invalid date")
+	}
+}
+
+func TestOverdue(t *testing.T) {
+	due, err := todo.ParseDue("2026-11-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name string
+		item todo.Todo
+		now  time.Time
+		exp  bool
+	}{
+		{name: "NoDue", item: todo.Todo{}, now: due.AddDate(1, 0, 0), exp: false},
+		{name: "Before", item: todo.Todo{Due: due}, now: due.Add(-time.Hour), exp: false},
+		{name: "DueDay", item: todo.Todo{Due: due}, now: due.Add(23 * time.Hour), exp: false},
+		{name: "After", item: todo.Todo{Due: due}, now: due.AddDate(0, 0, 1), exp: true},
+		{name: "Done", item: todo.Todo{Due: due, Done: true}, now: due.AddDate(0, 0, 2), exp: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if res := tc.item.Overdue(tc.now); res != tc.exp {
+				t.Errorf("expected %t but got %t", tc.exp, res)
+			}
+		})
+	}
+}
+
+func TestStringAttributes(t *testing.T) {
+	list := todo.List{}
+	list.Add("(A) late task due:2000-01-01")
+	list.Add("(B) later task +work due:2999-01-01")
+	list.Add("done task due:2000-01-01")
+	if err := list.Complete(3); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(list.String(), "\n"), "\n")
+	exp := []string{
+		"!  1 " + list[0].ID + ": (A) late task due:2000-01-01",
+		"  2 " + list[1].ID + ": (B) later task +work due:2999-01-01",
+		"X  3 " + list[2].ID + ": done task due:2000-01-01",
+	}
+
+	if !slices.Equal(lines, exp) {
+		t.Errorf("expected %q but got %q", exp, lines)
+	}
+}
@@ -0,0 +1,8 @@
+package main
+
+import "errors"
+
+var (
+	ErrUsage     = errors.New("invalid usage")
+	ErrNoCommand = errors.New("no command given")
+)
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ankitjha420/todo"
+)
+
+// taskOptions are the flags shared by add and edit, nil when not given
+type taskOptions struct {
+	priority *string
+	due      *time.Time
+	tags     []string
+}
+
+// parseTaskFlags reads the flags anywhere among the arguments and returns the other
+// words in order, everything after -- is kept as text. "none" clears the priority or
+// due date
+func parseTaskFlags(cmd string, args []string) (taskOptions, []string, error) {
+	var opts taskOptions
+
+	fs := flag.NewFlagSet(cmd, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	fs.Func("p", "Priority, A to E", func(s string) error {
+		if s == "none" {
+			s = ""
+		}
+		p, err := todo.ParsePriority(s)
+		if err != nil {
+			return err
+		}
+		opts.priority = &p
+		return nil
+	})
+
+	fs.Func("due", "Due date, YYYY-MM-DD", func(s string) error {
+		var due time.Time
+		if s != "none" {
+			d, err := todo.ParseDue(s)
+			if err != nil {
+				return err
+			}
+			due = d
+		}
+		opts.due = &due
+		return nil
+	})
+
+	fs.Func("tag", "+project or @context, repeatable", func(s string) error {
+		if len(s) < 2 || (s[0] != '+' && s[0] != '@') || strings.ContainsAny(s, " \t") {
+			return fmt.Errorf("invalid tag %q, expected +project or @context", s)
+		}
+		opts.tags = append(opts.tags, s)
+		return nil
+	})
+
+	var words, text []string
+	if i := slices.Index(args, "--"); i >= 0 {
+		args, text = args[:i], args[i+1:]
+	}
+
+	// the flag package stops at the first word, so parsing resumes after each one
+	for {
+		if err := fs.Parse(args); err != nil {
+			if err == flag.ErrHelp {
+				return opts, nil, err
+			}
+			return opts, nil, fmt.Errorf("%w: %s", ErrUsage, err)
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		words = append(words, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+
+	return opts, append(words, text...), nil
+}
+
+func (o taskOptions) empty() bool {
+	return o.priority == nil && o.due == nil && len(o.tags) == 0
+}
+
+// apply sets the priority and due date of item i, tags are part of the text
+func (o taskOptions) apply(l *todo.List, i int) error {
+	if o.priority != nil {
+		if err := l.SetPriority(i, *o.priority); err != nil {
+			return err
+		}
+	}
+	if o.due != nil {
+		if err := l.SetDue(i, *o.due); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
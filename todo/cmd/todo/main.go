@@ -1,61 +1,251 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
-	"github.com/ankitjha420/todo"
+	"io"
 	"os"
+	"strings"
+	"time"
+
+	"github.com/ankitjha420/todo"
+	"github.com/ankitjha420/todo/filter"
 )
 
 const todoFileName = ".todo.json"
 
+const usage = `Usage: todo <command> [arguments]
+
+Commands:
+  add [FLAGS] TASK...           Add a task, the words are joined with spaces
+  ls [--sort KEYS] [QUERY...]   List the tasks matching the query with their IDs, X
+                                marks the completed ones and ! the overdue ones
+  done REF                      Complete a task
+  reopen REF                    Mark a task as not completed
+  edit [FLAGS] REF [TASK...]    Replace the text of a task, or only change what
+                                the flags set
+  rm REF                        Delete a task
+  help                          Show this help
+
+Flags of add and edit, before or after the other arguments:
+  -p PRIORITY    Priority, A (most urgent) to E, none to clear
+  -due DATE      Due date as YYYY-MM-DD, none to clear
+  -tag TAG       Add a +project or @context tag, repeatable
+Words after -- are always task text, even when they look like flags.
+
+The task text can carry the same, todo.txt style: "(A) call the bank +home
+@phone due:2026-11-01" sets priority A and the due date, +project and @context
+words are kept in the text as tags.
+
+A query is made of terms that all have to match, - in front of a term negates it:
+  +project @context         tags
+  status:open               open, done, overdue or all
+  priority>=B               A is the highest, also <, <=, >, = and priority:none
+  due<2026-11-01            also due:today, due<=tomorrow, due:none, due:any
+  created>=yesterday        when the task was added
+  word                      text of the task, ignoring case
+for example: todo ls status:open due<2026-11-01 +backend -@waiting priority>=B
+
+--sort takes comma separated keys out of due, priority, created, status, text and
+id, a - in front of a key reverses it: todo ls --sort due,-priority
+
+REF is either the number shown by ls or the task ID. Numbers change when tasks
+are deleted, IDs never do, so scripts should use IDs.
+
+Tasks are kept in .todo.json in the current directory. Commands that change it
+take a lock on .todo.json.lock, so they can run at the same time, and refuse to
+save over changes made by other programs in the meantime.
+`
+
 func main() {
-	// available CLI args
-	task := flag.String(
-		"task",
-		"",
-		"Task to be included in the Todo list",
-	)
-	list := flag.Bool("list", false, "List all tasks")
-	complete := flag.Int("complete", 0, "Item to be completed")
-	flag.Parse()
-
-	l := &todo.List{}
-
-	if err := l.Get(todoFileName); err != nil {
-		_, _ = fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
-	}
-
-	switch {
-	case *list:
-		for _, item := range *l {
-			if !item.Done {
-				fmt.Print(list)
-			}
+	err := run(os.Args[1:], os.Stdout)
+	if err == nil {
+		return
+	}
+
+	// -h after a command
+	if errors.Is(err, flag.ErrHelp) {
+		_, _ = fmt.Fprint(os.Stdout, usage)
+		return
+	}
+
+	_, _ = fmt.Fprintln(os.Stderr, err)
+
+	// exit code 2 is for misuse, same as the flag package
+	if errors.Is(err, ErrUsage) || errors.Is(err, ErrNoCommand) {
+		_, _ = fmt.Fprint(os.Stderr, "\n"+usage)
+		os.Exit(2)
+	}
+	os.Exit(1)
+}
+
+// run executes one subcommand against the list in todoFileName, saving it when the
+// command changed it
+func run(args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return ErrNoCommand
+	}
+
+	cmd, args := args[0], args[1:]
+	if cmd == "help" || cmd == "-h" || cmd == "-help" || cmd == "--help" {
+		_, err := fmt.Fprint(out, usage)
+		return err
+	}
+
+	// ls only reads, saves replace the file in a single rename so it never sees half
+	// of one and needs no lock
+	if cmd == "ls" {
+		l := &todo.List{}
+		if err := l.Get(todoFileName); err != nil {
+			return err
 		}
+		return listTasks(l, args, out)
+	}
 
-	case *complete > 0:
-		if err := l.Complete(*complete); err != nil {
-			_, _ = fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
+	// everything else reads, changes and saves the list holding the lock
+	tx, err := todo.Begin(todoFileName)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	l := &tx.List
+
+	switch cmd {
+	case "add":
+		opts, args, err := parseTaskFlags(cmd, args)
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 0 {
+			return fmt.Errorf("%w: add needs a task", ErrUsage)
 		}
 
-		if err := l.Save(todoFileName); err != nil {
-			_, _ = fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
+		// the text can be nothing but a priority or due date, the list is not saved
+		// when that leaves no task
+		l.Add(strings.Join(append(args, opts.tags...), " "))
+		if (*l)[len(*l)-1].Task == "" {
+			return fmt.Errorf("%w: add needs a task", ErrUsage)
+		}
+		if err := opts.apply(l, len(*l)); err != nil {
+			return err
+		}
+
+	case "done", "reopen", "rm":
+		if len(args) != 1 {
+			return fmt.Errorf("%w: %s needs exactly one task", ErrUsage, cmd)
 		}
 
-	case *task != "":
-		l.Add(*task)
-		if err := l.Save(todoFileName); err != nil {
-			_, _ = fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
+		i, err := l.Resolve(args[0])
+		if err != nil {
+			return err
+		}
+
+		switch cmd {
+		case "done":
+			err = l.Complete(i)
+		case "reopen":
+			err = l.Reopen(i)
+		case "rm":
+			err = l.Delete(i)
+		}
+		if err != nil {
+			return err
+		}
+
+	case "edit":
+		opts, args, err := parseTaskFlags(cmd, args)
+		if err != nil {
+			return err
+		}
+		if len(args) == 0 || (len(args) == 1 && opts.empty()) {
+			return fmt.Errorf("%w: edit needs a task and its new text or flags", ErrUsage)
+		}
+
+		i, err := l.Resolve(args[0])
+		if err != nil {
+			return err
+		}
+
+		// without new text the tags go onto the old one
+		text := strings.Join(args[1:], " ")
+		if len(args) == 1 && len(opts.tags) > 0 {
+			text = (*l)[i-1].Task
+		}
+		if text != "" || len(args) > 1 {
+			if err := l.Edit(i, strings.Join(append([]string{text}, opts.tags...), " ")); err != nil {
+				return err
+			}
+		}
+
+		if err := opts.apply(l, i); err != nil {
+			return err
 		}
 
 	default:
-		// invalid flag(s)
-		_, _ = fmt.Fprintln(os.Stderr, "invalid options")
-		os.Exit(1)
+		return fmt.Errorf("%w: unknown command %q", ErrUsage, cmd)
+	}
+
+	return tx.Commit()
+}
+
+// listTasks prints the tasks matching the query of ls in the order it asked for
+func listTasks(l *todo.List, args []string, out io.Writer) error {
+	query, keys, err := parseList(args)
+	if err != nil {
+		return err
 	}
+
+	f, err := filter.Parse(query, time.Now())
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrUsage, err)
+	}
+
+	positions := f.Select(*l)
+	filter.Sort(*l, positions, keys)
+
+	_, err = fmt.Fprint(out, l.Render(positions))
+	return err
+}
+
+// parseList splits the arguments of ls into the query and the sort keys. The flag
+// package is not used as negated terms such as -@waiting look like flags
+func parseList(args []string) (string, []filter.SortKey, error) {
+	var query []string
+	var keys []filter.SortKey
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		switch {
+		case arg == "--":
+			query = append(query, args[i+1:]...)
+			i = len(args)
+			continue
+		case arg == "-h" || arg == "-help" || arg == "--help":
+			return "", nil, flag.ErrHelp
+		case arg != "--sort" && arg != "-sort" && !strings.HasPrefix(arg, "--sort=") && !strings.HasPrefix(arg, "-sort="):
+			query = append(query, arg)
+			continue
+		}
+
+		_, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			if i+1 == len(args) {
+				return "", nil, fmt.Errorf("%w: --sort needs a list of keys", ErrUsage)
+			}
+			i++
+			value = args[i]
+		}
+
+		k, err := filter.ParseSort(value)
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: %s", ErrUsage, err)
+		}
+		keys = append(keys, k...)
+	}
+
+	return strings.Join(query, " "), keys, nil
 }
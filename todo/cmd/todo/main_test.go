@@ -1,17 +1,23 @@
 package main_test
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"strconv"
+	"strings"
 	"testing"
 )
 
 var (
-	binName  = "todo"
-	fileName = ".todo.json"
+	binName = "todo"
+
+	// IDs differ between runs, so ls output is compared with every ID replaced by "ID"
+	idPattern = regexp.MustCompile(`(?m)^([X!]?\s+(\d+)) ([b-z]{6}):`)
 )
 
 func TestMain(m *testing.M) {
@@ -31,7 +37,6 @@ func TestMain(m *testing.M) {
 
 	fmt.Println("Cleaning up...")
 	_ = os.Remove(binName)
-	_ = os.Remove(fileName)
 
 	os.Exit(res)
 }
@@ -45,23 +50,228 @@ func TestTodoCLI(t *testing.T) {
 
 	cmdPath := filepath.Join(dir, binName)
 
+	// every run works on the same list, kept away from the one in the repo
+	workDir := t.TempDir()
+	todo := func(args ...string) *exec.Cmd {
+		cmd := exec.Command(cmdPath, args...)
+		cmd.Dir = workDir
+		return cmd
+	}
+
+	ls := func(t *testing.T, args ...string) string {
+		t.Helper()
+
+		out, err := todo(append([]string{"ls"}, args...)...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("Failed to list tasks: %v", err)
+		}
+		return string(out)
+	}
+
+	list := func(t *testing.T, expected string, args ...string) {
+		t.Helper()
+
+		out := idPattern.ReplaceAllString(ls(t, args...), "$1 ID:")
+		if out != expected {
+			t.Errorf("Expected %q but got %q", expected, out)
+		}
+	}
+
+	// id returns the ID ls shows for the task at position n
+	id := func(t *testing.T, n int) string {
+		t.Helper()
+
+		for _, m := range idPattern.FindAllStringSubmatch(ls(t), -1) {
+			if m[2] == strconv.Itoa(n) {
+				return m[3]
+			}
+		}
+
+		t.Fatalf("No task at position %d", n)
+		return ""
+	}
+
 	t.Run("AddNewTask", func(t *testing.T) {
-		cmd := exec.Command(cmdPath, "-task", task)
+		cmd := todo("add", task)
 		if err := cmd.Run(); err != nil {
 			t.Fatalf("Failed to add task: %v", err)
 		}
 	})
 
 	t.Run("ListTasks", func(t *testing.T) {
-		cmd := exec.Command(cmdPath, "-list")
-		out, err := cmd.CombinedOutput()
+		list(t, fmt.Sprintf("  1 ID: %s\n", task))
+	})
+
+	t.Run("AddWords", func(t *testing.T) {
+		if err := todo("add", "second", "task").Run(); err != nil {
+			t.Fatalf("Failed to add task: %v", err)
+		}
+		list(t, fmt.Sprintf("  1 ID: %s\n  2 ID: second task\n", task))
+	})
+
+	t.Run("CompleteTask", func(t *testing.T) {
+		if err := todo("done", "1").Run(); err != nil {
+			t.Fatalf("Failed to complete task: %v", err)
+		}
+		list(t, fmt.Sprintf("X  1 ID: %s\n  2 ID: second task\n", task))
+	})
+
+	t.Run("ReopenTask", func(t *testing.T) {
+		if err := todo("reopen", "1").Run(); err != nil {
+			t.Fatalf("Failed to reopen task: %v", err)
+		}
+		list(t, fmt.Sprintf("  1 ID: %s\n  2 ID: second task\n", task))
+	})
+
+	t.Run("EditTask", func(t *testing.T) {
+		if err := todo("edit", "2", "edited", "task").Run(); err != nil {
+			t.Fatalf("Failed to edit task: %v", err)
+		}
+		list(t, fmt.Sprintf("  1 ID: %s\n  2 ID: edited task\n", task))
+	})
+
+	t.Run("DeleteTask", func(t *testing.T) {
+		second := id(t, 2)
+
+		if err := todo("rm", "1").Run(); err != nil {
+			t.Fatalf("Failed to delete task: %v", err)
+		}
+		list(t, "  1 ID: edited task\n")
+
+		if res := id(t, 1); res != second {
+			t.Errorf("Expected ID %q to survive the delete but got %q", second, res)
+		}
+	})
+
+	t.Run("CompleteByID", func(t *testing.T) {
+		if err := todo("add", "third task").Run(); err != nil {
+			t.Fatalf("Failed to add task: %v", err)
+		}
+		third := id(t, 2)
+
+		if err := todo("done", third).Run(); err != nil {
+			t.Fatalf("Failed to complete task: %v", err)
+		}
+		if err := todo("rm", third).Run(); err != nil {
+			t.Fatalf("Failed to delete task: %v", err)
+		}
+		list(t, "  1 ID: edited task\n")
+	})
+
+	t.Run("Help", func(t *testing.T) {
+		out, err := todo("help").Output()
 		if err != nil {
-			t.Fatalf("Failed to list tasks: %v", err)
+			t.Fatalf("Failed to show help: %v", err)
 		}
+		if len(out) == 0 {
+			t.Error("Expected usage but got nothing")
+		}
+	})
+
+	errorCases := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{name: "NoCommand", args: nil, code: 2},
+		{name: "UnknownCommand", args: []string{"frobnicate"}, code: 2},
+		{name: "EmptyTask", args: []string{"add"}, code: 2},
+		{name: "UnknownID", args: []string{"done", "bcdfgh"}, code: 1},
+		{name: "MissingTask", args: []string{"done", "9"}, code: 1},
+		{name: "EmptyEdit", args: []string{"edit", "1", ""}, code: 1},
+		{name: "OnlyPriority", args: []string{"add", "(B)"}, code: 2},
+		{name: "EditOnlyPriority", args: []string{"edit", "1", "(C)"}, code: 1},
+		{name: "InvalidPriority", args: []string{"add", "-p", "Z", "task"}, code: 2},
+		{name: "InvalidDue", args: []string{"edit", "-due", "tomorrow", "1"}, code: 2},
+		{name: "InvalidTag", args: []string{"add", "-tag", "work", "task"}, code: 2},
+		{name: "EditNothing", args: []string{"edit", "1"}, code: 2},
+		{name: "InvalidQuery", args: []string{"ls", "colour:red"}, code: 2},
+		{name: "InvalidSort", args: []string{"ls", "--sort", "size"}, code: 2},
+		{name: "MissingSort", args: []string{"ls", "--sort"}, code: 2},
+	}
+
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := todo(tc.args...).Run()
 
-		expected := fmt.Sprintf("  1: %s\n", task)
-		if string(out) != expected {
-			t.Errorf("Expected %q but got %q", expected, string(out))
+			var exitErr *exec.ExitError
+			if !errors.As(err, &exitErr) {
+				t.Fatalf("Expected exit code %d but got %v", tc.code, err)
+			}
+			if exitErr.ExitCode() != tc.code {
+				t.Errorf("Expected exit code %d but got %d", tc.code, exitErr.ExitCode())
+			}
+		})
+	}
+
+	t.Run("ListUnchanged", func(t *testing.T) {
+		list(t, "  1 ID: edited task\n")
+	})
+
+	t.Run("AddWithFlags", func(t *testing.T) {
+		if err := todo("add", "-p", "a", "-due", "2000-01-01", "-tag", "+work", "fix bug @desk").Run(); err != nil {
+			t.Fatalf("Failed to add task: %v", err)
+		}
+		list(t, "  1 ID: edited task\n!  2 ID: (A) fix bug @desk +work due:2000-01-01\n")
+	})
+
+	t.Run("EditFlags", func(t *testing.T) {
+		if err := todo("edit", "-p", "none", "-due", "2999-01-01", "2").Run(); err != nil {
+			t.Fatalf("Failed to edit task: %v", err)
+		}
+		if err := todo("edit", "-p", "C", "1", "(B) edited again +home").Run(); err != nil {
+			t.Fatalf("Failed to edit task: %v", err)
+		}
+		list(t, "  1 ID: (C) edited again +home\n  2 ID: fix bug @desk +work due:2999-01-01\n")
+	})
+
+	t.Run("ListQuery", func(t *testing.T) {
+		list(t, "  2 ID: fix bug @desk +work due:2999-01-01\n", "+work", "status:open")
+		list(t, "  1 ID: (C) edited again +home\n", "-@desk")
+		list(t, "", "priority>C")
+	})
+
+	t.Run("ListSort", func(t *testing.T) {
+		exp := "  2 ID: fix bug @desk +work due:2999-01-01\n  1 ID: (C) edited again +home\n"
+		list(t, exp, "--sort", "due")
+		list(t, exp, "--sort=-text", "--", "status:open")
+	})
+
+	t.Run("FlagsAfterText", func(t *testing.T) {
+		if err := todo("edit", "1", "-p", "B").Run(); err != nil {
+			t.Fatalf("Failed to edit task: %v", err)
+		}
+		if err := todo("add", "buy", "milk", "-p", "B").Run(); err != nil {
+			t.Fatalf("Failed to add task: %v", err)
+		}
+		if err := todo("add", "-p", "D", "--", "-p", "is", "text").Run(); err != nil {
+			t.Fatalf("Failed to add task: %v", err)
+		}
+		list(t, "  1 ID: (B) edited again +home\n  3 ID: (B) buy milk\n  4 ID: (D) -p is text\n", "priority>=D")
+	})
+
+	t.Run("ConcurrentAdds", func(t *testing.T) {
+		n := 10
+
+		cmds := make([]*exec.Cmd, n)
+		for i := range cmds {
+			cmds[i] = todo("add", "concurrent task", strconv.Itoa(i))
+			if err := cmds[i].Start(); err != nil {
+				t.Fatalf("Failed to add task: %v", err)
+			}
+		}
+		for _, cmd := range cmds {
+			if err := cmd.Wait(); err != nil {
+				t.Fatalf("Failed to add task: %v", err)
+			}
+		}
+
+		out, err := todo("ls", "concurrent").Output()
+		if err != nil {
+			t.Fatalf("Failed to list tasks: %v", err)
+		}
+		if lines := strings.Count(string(out), "\n"); lines != n {
+			t.Errorf("Expected %d tasks but got %d", n, lines)
 		}
 	})
 }
@@ -0,0 +1,239 @@
+// Package filter selects and orders todo items with a small query language.
+//
+// A query is a list of terms separated by spaces, an item has to match every one of
+// them. A term starting with - matches the items the rest of it does not match.
+//
+//	+project           has the project tag
+//	@context           has the context tag
+//	status:open        open, done, overdue or all
+//	priority>=B        compared as A > B > ... > E, priority:none for no priority
+//	due<2026-11-01     due date compared with <, <=, >, >=, = or :, due:none, due:any
+//	created>=today     creation date, dates are YYYY-MM-DD, today, tomorrow or yesterday
+//	word               anything else is searched for in the task text, ignoring case
+//
+// A word that looks like a field, such as foo:bar, but names none of the above is an
+// error rather than a text search, so typos do not silently match nothing.
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ankitjha420/todo"
+)
+
+var (
+	ErrInvalidQuery = errors.New("invalid query")
+	ErrInvalidSort  = errors.New("invalid sort")
+)
+
+// a field, an operator and a value, such as due<=today
+var fieldPattern = regexp.MustCompile(`^([a-z]+)(<=|>=|<|>|=|:)(.*)$`)
+
+// Filter is a parsed query
+type Filter struct {
+	terms []term
+	now   time.Time
+}
+
+type term struct {
+	negate bool
+	match  func(t todo.Todo) bool
+}
+
+// Parse reads a query, now is what today, status:overdue and the like are relative to
+func Parse(query string, now time.Time) (*Filter, error) {
+	f := &Filter{now: now}
+
+	for _, word := range strings.Fields(query) {
+		t, err := f.parseTerm(word)
+		if err != nil {
+			return nil, err
+		}
+		f.terms = append(f.terms, t)
+	}
+
+	return f, nil
+}
+
+func (f *Filter) parseTerm(word string) (term, error) {
+	t := term{}
+
+	s := word
+	if len(s) > 1 && s[0] == '-' {
+		t.negate, s = true, s[1:]
+	}
+
+	switch {
+	case len(s) > 1 && s[0] == '+':
+		t.match = func(item todo.Todo) bool { return slices.Contains(item.Projects, s[1:]) }
+		return t, nil
+	case len(s) > 1 && s[0] == '@':
+		t.match = func(item todo.Todo) bool { return slices.Contains(item.Contexts, s[1:]) }
+		return t, nil
+	}
+
+	m := fieldPattern.FindStringSubmatch(s)
+	if m == nil {
+		text := strings.ToLower(s)
+		t.match = func(item todo.Todo) bool { return strings.Contains(strings.ToLower(item.Task), text) }
+		return t, nil
+	}
+
+	var err error
+	field, op, value := m[1], m[2], m[3]
+
+	switch field {
+	case "status":
+		t.match, err = f.status(op, value)
+	case "priority":
+		t.match, err = priority(op, value)
+	case "due":
+		t.match, err = f.date(op, value, func(item todo.Todo) time.Time { return item.Due })
+	case "created":
+		t.match, err = f.date(op, value, func(item todo.Todo) time.Time { return item.CreatedAt })
+	default:
+		err = fmt.Errorf("unknown field %q", field)
+	}
+	if err != nil {
+		return term{}, fmt.Errorf("%w: %s: %s", ErrInvalidQuery, word, err)
+	}
+
+	return t, nil
+}
+
+// Match reports whether the item matches every term
+func (f *Filter) Match(item todo.Todo) bool {
+	for _, t := range f.terms {
+		if t.match(item) == t.negate {
+			return false
+		}
+	}
+	return true
+}
+
+// Select returns the 1-based positions of the matching items, in list order
+func (f *Filter) Select(list todo.List) []int {
+	var positions []int
+
+	for k, item := range list {
+		if f.Match(item) {
+			positions = append(positions, k+1)
+		}
+	}
+
+	return positions
+}
+
+func (f *Filter) status(op, value string) (func(todo.Todo) bool, error) {
+	if op != ":" && op != "=" {
+		return nil, fmt.Errorf("status only supports :")
+	}
+
+	switch value {
+	case "open":
+		return func(item todo.Todo) bool { return !item.Done }, nil
+	case "done":
+		return func(item todo.Todo) bool { return item.Done }, nil
+	case "overdue":
+		return func(item todo.Todo) bool { return item.Overdue(f.now) }, nil
+	case "all":
+		return func(item todo.Todo) bool { return true }, nil
+	}
+
+	return nil, fmt.Errorf("expected open, done, overdue or all")
+}
+
+func priority(op, value string) (func(todo.Todo) bool, error) {
+	if value == "none" {
+		if op != ":" && op != "=" {
+			return nil, fmt.Errorf("priority:none only supports :")
+		}
+		return func(item todo.Todo) bool { return item.Priority == "" }, nil
+	}
+
+	p, err := todo.ParsePriority(value)
+	if err != nil || p == "" {
+		return nil, fmt.Errorf("expected a priority from A to E or none")
+	}
+
+	want := urgency(p)
+	return func(item todo.Todo) bool {
+		return item.Priority != "" && compare(op, urgency(item.Priority)-want)
+	}, nil
+}
+
+// urgency ranks priorities so that A is the highest, no priority is 0
+func urgency(p string) int {
+	if i := slices.Index(todo.Priorities, p); i >= 0 {
+		return len(todo.Priorities) - i
+	}
+	return 0
+}
+
+// date compares the day of the field against the value, items without the field only
+// match due:none
+func (f *Filter) date(op, value string, field func(todo.Todo) time.Time) (func(todo.Todo) bool, error) {
+	if value == "none" || value == "any" {
+		if op != ":" && op != "=" {
+			return nil, fmt.Errorf("%s only supports :", value)
+		}
+
+		none := value == "none"
+		return func(item todo.Todo) bool { return field(item).IsZero() == none }, nil
+	}
+
+	d, err := f.parseDate(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(item todo.Todo) bool {
+		v := field(item)
+		return !v.IsZero() && compare(op, day(v).Compare(d))
+	}, nil
+}
+
+func (f *Filter) parseDate(value string) (time.Time, error) {
+	today := day(f.now)
+
+	switch value {
+	case "today":
+		return today, nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	}
+
+	d, err := todo.ParseDue(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected YYYY-MM-DD, today, tomorrow or yesterday")
+	}
+	return d, nil
+}
+
+// day is midnight of the local day t falls on
+func day(t time.Time) time.Time {
+	t = t.In(time.Local)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// compare applies the operator to the result of a three way comparison
+func compare(op string, c int) bool {
+	switch op {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+	return c == 0
+}
@@ -0,0 +1,146 @@
+package filter_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ankitjha420/todo"
+	"github.com/ankitjha420/todo/filter"
+)
+
+func date(tb testing.TB, s string) time.Time {
+	tb.Helper()
+
+	d, err := todo.ParseDue(s)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return d
+}
+
+// testList is matched at noon on 2026-10-18
+func testList(tb testing.TB) (todo.List, time.Time) {
+	tb.Helper()
+
+	list := todo.List{
+		{ID: "bbbbbb", Task: "fix login bug +backend @work", Priority: "A", Due: date(tb, "2026-10-20"),
+			Projects: []string{"backend"}, Contexts: []string{"work"}, CreatedAt: date(tb, "2026-10-01")},
+		{ID: "cccccc", Task: "wait for review +backend @waiting", Priority: "C",
+			Projects: []string{"backend"}, Contexts: []string{"waiting"}, CreatedAt: date(tb, "2026-10-05")},
+		{ID: "dddddd", Task: "buy milk @errands", Due: date(tb, "2026-10-17"),
+			Contexts: []string{"errands"}, CreatedAt: date(tb, "2026-10-18").Add(9 * time.Hour)},
+		{ID: "ffffff", Task: "Write README +docs", Priority: "B", Due: date(tb, "2026-11-01"), Done: true,
+			Projects: []string{"docs"}, CreatedAt: date(tb, "2026-09-30")},
+		{ID: "gggggg", Task: "plan sprint", Priority: "E", Due: date(tb, "2026-10-18"), CreatedAt: date(tb, "2026-10-10")},
+	}
+
+	return list, date(tb, "2026-10-18").Add(12 * time.Hour)
+}
+
+func TestSelect(t *testing.T) {
+	list, now := testList(t)
+
+	testCases := []struct {
+		name  string
+		query string
+		exp   []int
+	}{
+		{name: "Empty", query: "", exp: []int{1, 2, 3, 4, 5}},
+		{name: "Project", query: "+backend", exp: []int{1, 2}},
+		{name: "Context", query: "@errands", exp: []int{3}},
+		{name: "NotContext", query: "-@waiting", exp: []int{1, 3, 4, 5}},
+		{name: "UnknownProject", query: "+nothing", exp: nil},
+		{name: "StatusOpen", query: "status:open", exp: []int{1, 2, 3, 5}},
+		{name: "StatusDone", query: "status:done", exp: []int{4}},
+		{name: "StatusOverdue", query: "status:overdue", exp: []int{3}},
+		{name: "StatusAll", query: "status=all", exp: []int{1, 2, 3, 4, 5}},
+		{name: "NotDone", query: "-status:done", exp: []int{1, 2, 3, 5}},
+		{name: "PriorityEqual", query: "priority:c", exp: []int{2}},
+		{name: "PriorityAtLeast", query: "priority>=B", exp: []int{1, 4}},
+		{name: "PriorityAbove", query: "priority>B", exp: []int{1}},
+		{name: "PriorityBelow", query: "priority<C", exp: []int{5}},
+		{name: "PriorityAtMost", query: "priority<=C", exp: []int{2, 5}},
+		{name: "PriorityNone", query: "priority:none", exp: []int{3}},
+		{name: "DueBefore", query: "due<2026-11-01", exp: []int{1, 3, 5}},
+		{name: "DueAtMost", query: "due<=2026-11-01", exp: []int{1, 3, 4, 5}},
+		{name: "DueAfter", query: "due>today", exp: []int{1, 4}},
+		{name: "DueAtLeast", query: "due>=today", exp: []int{1, 4, 5}},
+		{name: "DueToday", query: "due:today", exp: []int{5}},
+		{name: "DueYesterday", query: "due=yesterday", exp: []int{3}},
+		{name: "DueTomorrow", query: "due<=tomorrow", exp: []int{3, 5}},
+		{name: "DueNone", query: "due:none", exp: []int{2}},
+		{name: "DueAny", query: "due:any", exp: []int{1, 3, 4, 5}},
+		{name: "NotDueNone", query: "-due:none", exp: []int{1, 3, 4, 5}},
+		{name: "CreatedToday", query: "created:today", exp: []int{3}},
+		{name: "CreatedBefore", query: "created<2026-10-05", exp: []int{1, 4}},
+		{name: "Text", query: "readme", exp: []int{4}},
+		{name: "NotText", query: "-bug", exp: []int{2, 3, 4, 5}},
+		{name: "TextCase", query: "FIX", exp: []int{1}},
+		{
+			name:  "Combined",
+			query: "status:open due<2026-11-01 +backend -@waiting priority>=B",
+			exp:   []int{1},
+		},
+		{name: "CombinedNone", query: "+backend @errands", exp: nil},
+		{name: "LoneDash", query: "-", exp: nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := filter.Parse(tc.query, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			res := f.Select(list)
+			if !slices.Equal(res, tc.exp) {
+				t.Errorf("Expected %v but got %v", tc.exp, res)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	queries := []string{
+		"status:later",
+		"status<open",
+		"priority:F",
+		"priority>none",
+		"priority:",
+		"due<soon",
+		"due<none",
+		"created:11/01/2026",
+		"colour:red",
+		"+backend -stauts:done",
+	}
+
+	for _, q := range queries {
+		t.Run(q, func(t *testing.T) {
+			_, err := filter.Parse(q, time.Now())
+			if !errors.Is(err, filter.ErrInvalidQuery) {
+				t.Errorf("Expected error %q, got %v instead", filter.ErrInvalidQuery, err)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	list, now := testList(t)
+
+	f, err := filter.Parse("+backend -@waiting", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !f.Match(list[0]) {
+		t.Errorf("Expected %q to match", list[0].Task)
+	}
+	if f.Match(list[1]) {
+		t.Errorf("Expected %q not to match", list[1].Task)
+	}
+	if f.Match(todo.Todo{}) {
+		t.Error("Expected an empty item not to match")
+	}
+}
@@ -0,0 +1,101 @@
+package filter
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ankitjha420/todo"
+)
+
+// SortFields are the fields items can be sorted by
+var SortFields = []string{"due", "priority", "created", "status", "text", "id"}
+
+// SortKey is one field to sort by, later keys break ties of the earlier ones
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+// ParseSort reads comma separated fields such as "due,-priority", a leading - sorts in
+// descending order
+func ParseSort(s string) ([]SortKey, error) {
+	var keys []SortKey
+
+	for _, f := range strings.Split(s, ",") {
+		k := SortKey{}
+		k.Field, k.Desc = strings.CutPrefix(strings.TrimSpace(f), "-")
+
+		if !slices.Contains(SortFields, k.Field) {
+			return nil, fmt.Errorf("%w: unknown field %q, expected one of %s", ErrInvalidSort, k.Field, strings.Join(SortFields, ", "))
+		}
+		keys = append(keys, k)
+	}
+
+	return keys, nil
+}
+
+// Sort orders the 1-based positions of list by the keys, keeping list order for ties.
+// Items without a due date or priority come last either way
+func Sort(list todo.List, positions []int, keys []SortKey) {
+	slices.SortStableFunc(positions, func(i, j int) int {
+		a, b := list[i-1], list[j-1]
+
+		for _, k := range keys {
+			c, missing := compareField(k.Field, a, b)
+			if c == 0 {
+				continue
+			}
+			if k.Desc && !missing {
+				c = -c
+			}
+			return c
+		}
+
+		return 0
+	})
+}
+
+// compareField compares a and b on one field, missing is set when only one of them
+// has the field, which then sorts first
+func compareField(field string, a, b todo.Todo) (c int, missing bool) {
+	switch field {
+	case "due":
+		if a.Due.IsZero() != b.Due.IsZero() {
+			return present(!a.Due.IsZero()), true
+		}
+		return a.Due.Compare(b.Due), false
+	case "priority":
+		if (a.Priority == "") != (b.Priority == "") {
+			return present(a.Priority != ""), true
+		}
+		return urgency(b.Priority) - urgency(a.Priority), false
+	case "created":
+		return a.CreatedAt.Compare(b.CreatedAt), false
+	case "status":
+		return cmp.Compare(status(a), status(b)), false
+	case "text":
+		return strings.Compare(strings.ToLower(a.Task), strings.ToLower(b.Task)), false
+	case "id":
+		return strings.Compare(a.ID, b.ID), false
+	}
+
+	return 0, false
+}
+
+// present sorts the item that has a field first
+func present(a bool) int {
+	if a {
+		return -1
+	}
+	return 1
+}
+
+// status sorts open items before done ones
+func status(t todo.Todo) int {
+	if t.Done {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,74 @@
+package filter_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/ankitjha420/todo/filter"
+)
+
+func TestSort(t *testing.T) {
+	list, _ := testList(t)
+
+	testCases := []struct {
+		name string
+		sort string
+		exp  []int
+	}{
+		{name: "Due", sort: "due", exp: []int{3, 5, 1, 4, 2}},
+		{name: "DueDesc", sort: "-due", exp: []int{4, 1, 5, 3, 2}},
+		{name: "Priority", sort: "priority", exp: []int{1, 4, 2, 5, 3}},
+		{name: "PriorityDesc", sort: "-priority", exp: []int{5, 2, 4, 1, 3}},
+		{name: "Created", sort: "created", exp: []int{4, 1, 2, 5, 3}},
+		{name: "Status", sort: "status", exp: []int{1, 2, 3, 5, 4}},
+		{name: "Text", sort: "text", exp: []int{3, 1, 5, 2, 4}},
+		{name: "ID", sort: "-id", exp: []int{5, 4, 3, 2, 1}},
+		{name: "StatusDue", sort: "status,due", exp: []int{3, 5, 1, 2, 4}},
+		{name: "Spaces", sort: "status, -priority", exp: []int{5, 2, 1, 3, 4}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			keys, err := filter.ParseSort(tc.sort)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			positions := []int{1, 2, 3, 4, 5}
+			filter.Sort(list, positions, keys)
+
+			if !slices.Equal(positions, tc.exp) {
+				t.Errorf("Expected %v but got %v", tc.exp, positions)
+			}
+		})
+	}
+}
+
+func TestSortSubset(t *testing.T) {
+	list, _ := testList(t)
+
+	keys, err := filter.ParseSort("priority")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	positions := []int{5, 3, 2}
+	filter.Sort(list, positions, keys)
+
+	exp := []int{2, 5, 3}
+	if !slices.Equal(positions, exp) {
+		t.Errorf("Expected %v but got %v", exp, positions)
+	}
+}
+
+func TestParseSortErrors(t *testing.T) {
+	for _, s := range []string{"", "size", "due,", "due,-"} {
+		t.Run(s, func(t *testing.T) {
+			_, err := filter.ParseSort(s)
+			if !errors.Is(err, filter.ErrInvalidSort) {
+				t.Errorf("Expected error %q, got %v instead", filter.ErrInvalidSort, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package todo
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+)
+
+// fileLock is a file that only exists while the lock is held, a process that dies
+// holding it leaves it behind and it has to be removed by hand
+type fileLock struct {
+	name string
+	file *os.File
+}
+
+// tryLock takes the lock if nobody holds it, ok is false when somebody does
+func tryLock(name string) (lock *fileLock, ok bool, err error) {
+	file, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+	if errors.Is(err, fs.ErrExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &fileLock{name: name, file: file}, true, nil
+}
+
+func (l *fileLock) unlock() error {
+	err := l.file.Close()
+	if rerr := os.Remove(l.name); err == nil {
+		err = rerr
+	}
+	return err
+}
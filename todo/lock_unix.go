@@ -0,0 +1,43 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package todo
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// fileLock is a flock on a file kept next to the list. The file is never removed,
+// removing it would let two processes lock different files of the same name
+type fileLock struct {
+	file *os.File
+}
+
+// tryLock takes the lock if nobody holds it, ok is false when somebody does
+func tryLock(name string) (lock *fileLock, ok bool, err error) {
+	file, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, false, err
+	}
+
+	err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		file.Close()
+		return nil, false, nil
+	}
+	if err != nil {
+		file.Close()
+		return nil, false, err
+	}
+
+	return &fileLock{file: file}, true, nil
+}
+
+func (l *fileLock) unlock() error {
+	err := syscall.Flock(int(l.file.Fd()), syscall.LOCK_UN)
+	if cerr := l.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -1,17 +1,34 @@
 package todo
 
 import (
+	"crypto/sha256"
+	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"strconv"
 	"time"
 )
 
+// IDs are made of consonants other than l, so they never read as a display index or a word
+const (
+	idAlphabet = "bcdfghjkmnpqrstvwxz"
+	idLen      = 6
+)
+
 type Todo struct {
+	ID          string    `json:"id"`
 	Task        string    `json:"task"`
 	Done        bool      `json:"done"`
 	CreatedAt   time.Time `json:"createdAt"`
 	CompletedAt time.Time `json:"completedAt"`
+	Priority    string    `json:"priority,omitempty"`
+	Due         time.Time `json:"due"`
+	Projects    []string  `json:"projects,omitempty"`
+	Contexts    []string  `json:"contexts,omitempty"`
 }
 
 type List []Todo
@@ -23,9 +40,54 @@ func (list *List) Add(task string) {
 		CreatedAt: time.Now(),
 		CompletedAt: time.Time{},
 	}
+	todo.setText(task)
+	todo.ID = list.newID(todo)
 	*list = append(*list, todo)
 }
 
+// newID hashes the creation time and task into an ID that is not taken yet, the same
+// task always gets the same ID, so lists saved before IDs existed keep theirs
+func (list *List) newID(t Todo) string {
+	for salt := 0; ; salt++ {
+		sum := sha256.Sum256([]byte(fmt.Sprintf("%d\x00%s\x00%d", t.CreatedAt.UnixNano(), t.Task, salt)))
+		n := binary.BigEndian.Uint64(sum[:8])
+
+		id := make([]byte, idLen)
+		for i := range id {
+			id[i] = idAlphabet[n%uint64(len(idAlphabet))]
+			n /= uint64(len(idAlphabet))
+		}
+
+		if _, err := list.Index(string(id)); err != nil {
+			return string(id)
+		}
+	}
+}
+
+// Index returns the 1-based position of the item with the given ID
+func (list *List) Index(id string) (int, error) {
+	for k, t := range *list {
+		if t.ID == id {
+			return k + 1, nil
+		}
+	}
+
+	return 0, fmt.Errorf("todo item %s does not exist", id)
+}
+
+// Resolve turns what a user typed into a 1-based position, numbers are the position
+// shown by String and anything else is an ID
+func (list *List) Resolve(ref string) (int, error) {
+	if i, err := strconv.Atoi(ref); err == nil {
+		if i <= 0 || i > len(*list) {
+			return 0, fmt.Errorf("todo item %d does not exist", i)
+		}
+		return i, nil
+	}
+
+	return list.Index(ref)
+}
+
 func (list *List) Complete(i int) error {
 	if i <= 0 || i > len(*list) {
 		return fmt.Errorf("todo item %d does not exist", i)
@@ -38,6 +100,63 @@ func (list *List) Complete(i int) error {
 	return nil
 }
 
+func (list *List) Reopen(i int) error {
+	if i <= 0 || i > len(*list) {
+		return fmt.Errorf("todo item %d does not exist", i)
+	}
+
+	l := *list
+	l[i-1].Done = false
+	l[i-1].CompletedAt = time.Time{}
+
+	return nil
+}
+
+func (list *List) Edit(i int, task string) error {
+	if i <= 0 || i > len(*list) {
+		return fmt.Errorf("todo item %d does not exist", i)
+	}
+
+	// the text can be nothing but a priority or due date, which leaves no task
+	t := (*list)[i-1]
+	t.setText(task)
+	if t.Task == "" {
+		return fmt.Errorf("todo item %d cannot be empty", i)
+	}
+
+	(*list)[i-1] = t
+
+	return nil
+}
+
+func (list *List) SetPriority(i int, priority string) error {
+	if i <= 0 || i > len(*list) {
+		return fmt.Errorf("todo item %d does not exist", i)
+	}
+
+	p, err := ParsePriority(priority)
+	if err != nil {
+		return err
+	}
+
+	l := *list
+	l[i-1].Priority = p
+
+	return nil
+}
+
+// SetDue sets the due date of item i, the zero time clears it
+func (list *List) SetDue(i int, due time.Time) error {
+	if i <= 0 || i > len(*list) {
+		return fmt.Errorf("todo item %d does not exist", i)
+	}
+
+	l := *list
+	l[i-1].Due = due
+
+	return nil
+}
+
 func (list *List) Delete(i int) error {
 	if i <= 0 || i > len(*list) {
 		return fmt.Errorf("todo item %d does not exist", i)
@@ -55,37 +174,107 @@ func (list *List) Save(filename string) error {
 		return err
 	}
 
-	err = os.WriteFile(filename, j, 0644)
+	return writeFile(filename, j)
+}
+
+// writeFile replaces filename with data through a temporary file in the same
+// directory, so a crash leaves either the old or the new contents and never a
+// truncated file
+func writeFile(filename string, data []byte) error {
+	perm := fs.FileMode(0644)
+	if info, err := os.Stat(filename); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".*.tmp")
 	if err != nil {
 		return err
 	}
+	defer os.Remove(temp.Name())
 
-	return nil
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Sync(); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(temp.Name(), perm); err != nil {
+		return err
+	}
+
+	return os.Rename(temp.Name(), filename)
 }
 
 func (list *List) Get(filename string) error {
 	file, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
 	if err != nil {
 		return err
 	}
 
+	return list.load(file)
+}
+
+func (list *List) load(file []byte) error {
 	if len(file) == 0 {
 		return nil
 	}
 
-	return json.Unmarshal(file, list)
+	if err := json.Unmarshal(file, list); err != nil {
+		return err
+	}
+
+	l := *list
+	for k := range l {
+		if l[k].ID == "" {
+			l[k].ID = list.newID(l[k])
+		}
+	}
+
+	return nil
 }
 
 func(list *List) String() string {
+	positions := make([]int, len(*list))
+	for k := range positions {
+		positions[k] = k + 1
+	}
+
+	return list.Render(positions)
+}
+
+// Render formats the items at the given 1-based positions in that order, each with
+// the number String would show for it
+func (list *List) Render(positions []int) string {
 	formatted := ""
+	now := time.Now()
+
+	for _, i := range positions {
+		t := (*list)[i-1]
 
-	for k, t := range *list {
 		prefix := "  "
 		if t.Done {
 			prefix = "X  "
+		} else if t.Overdue(now) {
+			prefix = "!  "
+		}
+
+		task := t.Task
+		if t.Priority != "" {
+			task = fmt.Sprintf("(%s) %s", t.Priority, task)
+		}
+		if !t.Due.IsZero() {
+			task += " due:" + t.Due.Format(DateFormat)
 		}
 
-		formatted += fmt.Sprintf("%s%d:%s\n", prefix, k+1, t.Task)
+		formatted += fmt.Sprintf("%s%d %s: %s\n", prefix, i, t.ID, task)
 	}
 
 	return formatted
@@ -3,6 +3,7 @@ package todo_test
 import (
 	"github.com/ankitjha420/todo"
 	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -37,6 +38,48 @@ func TestComplete(t *testing.T) {
 	}
 }
 
+func TestReopen(t *testing.T) {
+	list := todo.List{}
+	list.Add("new task")
+
+	if err := list.Complete(1); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	err := list.Reopen(1)
+	if err != nil {
+		t.Error(err.Error())
+	} else if list[0].Done {
+		t.Error("expected false but got true")
+	} else if !list[0].CompletedAt.IsZero() {
+		t.Errorf("expected no completion time but got %v", list[0].CompletedAt)
+	}
+
+	if err := list.Reopen(2); err == nil {
+		t.Error("expected an error for a missing item")
+	}
+}
+
+func TestEdit(t *testing.T) {
+	list := todo.List{}
+	list.Add("new task")
+
+	task := "edited task"
+	err := list.Edit(1, task)
+	if err != nil {
+		t.Error(err.Error())
+	} else if list[0].Task != task {
+		t.Errorf("expected %s but got %s", task, list[0].Task)
+	}
+
+	if err := list.Edit(1, ""); err == nil {
+		t.Error("expected an error for an empty task")
+	}
+	if err := list.Edit(0, task); err == nil {
+		t.Error("expected an error for a missing item")
+	}
+}
+
 func TestDelete(t *testing.T) {
 	list := todo.List{}
 
@@ -63,6 +106,99 @@ func TestDelete(t *testing.T) {
 	}
 }
 
+func TestIDs(t *testing.T) {
+	list := todo.List{}
+	for _, v := range []string{"one", "two", "three"} {
+		list.Add(v)
+	}
+
+	seen := map[string]bool{}
+	for _, v := range list {
+		if len(v.ID) != 6 {
+			t.Errorf("expected a 6 character ID but got %q", v.ID)
+		}
+		if seen[v.ID] {
+			t.Errorf("expected unique IDs but got %s twice", v.ID)
+		}
+		seen[v.ID] = true
+	}
+
+	id := list[2].ID
+	if err := list.Delete(1); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	i, err := list.Resolve(id)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if i != 2 || list[i-1].Task != "three" {
+		t.Errorf("expected %s at 2 but got %d", id, i)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	list := todo.List{}
+	list.Add("one")
+	list.Add("two")
+
+	testCases := []struct {
+		name string
+		ref  string
+		exp  int
+		err  bool
+	}{
+		{name: "Index", ref: "2", exp: 2},
+		{name: "ID", ref: list[0].ID, exp: 1},
+		{name: "IndexOutOfRange", ref: "3", err: true},
+		{name: "Zero", ref: "0", err: true},
+		{name: "UnknownID", ref: "bcdfgh", err: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			i, err := list.Resolve(tc.ref)
+			if tc.err {
+				if err == nil {
+					t.Errorf("expected an error but got %d", i)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+			if i != tc.exp {
+				t.Errorf("expected %d but got %d", tc.exp, i)
+			}
+		})
+	}
+}
+
+func TestGetAssignsIDs(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "todo.json")
+	data := `[{"task": "old task", "done": false, "createdAt": "2006-01-02T15:04:05Z"}]`
+	if err := os.WriteFile(fname, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	l1 := todo.List{}
+	l2 := todo.List{}
+	if err := l1.Get(fname); err != nil {
+		t.Fatal(err)
+	}
+	if err := l2.Get(fname); err != nil {
+		t.Fatal(err)
+	}
+
+	if l1[0].ID == "" {
+		t.Error("expected an ID but got none")
+	}
+	if l1[0].ID != l2[0].ID {
+		t.Errorf("expected the same ID on every load but got %s and %s", l1[0].ID, l2[0].ID)
+	}
+}
+
 func TestSaveGet(t *testing.T) {
 	l1 := todo.List{}
 	l2 := todo.List{}
@@ -91,3 +227,14 @@ func TestSaveGet(t *testing.T) {
 		t.Errorf("task %s did not match %s", l1[0].Task, l2[0].Task)
 	}
 }
+
+func TestGetMissingFile(t *testing.T) {
+	list := todo.List{}
+
+	if err := list.Get(filepath.Join(t.TempDir(), "missing.json")); err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	if len(list) != 0 {
+		t.Errorf("expected length 0 but got %d", len(list))
+	}
+}
@@ -0,0 +1,138 @@
+package todo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+)
+
+var (
+	ErrLocked   = errors.New("todo file is locked by another process")
+	ErrModified = errors.New("todo file was changed by another program since it was read")
+)
+
+// LockTimeout is how long Begin waits for another process to let go of the lock
+var LockTimeout = 5 * time.Second
+
+// how often Begin tries the lock again while waiting
+const lockRetry = 20 * time.Millisecond
+
+// Tx is a read-modify-write of a todo file. It holds an advisory lock on
+// filename.lock from Begin until Commit or Rollback, so other processes going
+// through Begin wait their turn
+type Tx struct {
+	List List
+
+	filename string
+	lock     *fileLock
+	// contents read by Begin, nil when the file did not exist
+	read []byte
+}
+
+// Begin locks filename and reads the list in it, a missing file is an empty list
+func Begin(filename string) (*Tx, error) {
+	lock, err := acquire(filename + ".lock")
+	if err != nil {
+		return nil, err
+	}
+
+	tx := &Tx{filename: filename, lock: lock}
+
+	tx.read, err = readFile(filename)
+	if err == nil {
+		err = tx.List.load(tx.read)
+	}
+	if err != nil {
+		_ = lock.unlock()
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// Commit saves the list and releases the lock. Nothing is written and ErrModified
+// is returned when the file changed since Begin, such as by an editor or a program
+// that does not take the lock
+func (tx *Tx) Commit() error {
+	if tx.lock == nil {
+		return errors.New("transaction already finished")
+	}
+	defer tx.Rollback()
+
+	current, err := readFile(tx.filename)
+	if err != nil {
+		return err
+	}
+	if (current == nil) != (tx.read == nil) || !bytes.Equal(current, tx.read) {
+		return fmt.Errorf("%w: %s", ErrModified, tx.filename)
+	}
+
+	return tx.List.Save(tx.filename)
+}
+
+// Rollback releases the lock without saving, it does nothing after Commit
+func (tx *Tx) Rollback() error {
+	if tx.lock == nil {
+		return nil
+	}
+
+	err := tx.lock.unlock()
+	tx.lock = nil
+	return err
+}
+
+// Update runs fn inside a transaction on filename, the list is saved when fn
+// returns no error
+func Update(filename string, fn func(list *List) error) error {
+	tx, err := Begin(filename)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(&tx.List); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// readFile is os.ReadFile with nil contents for a missing file, an existing empty
+// file reads as an empty slice
+func readFile(filename string) ([]byte, error) {
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		data = []byte{}
+	}
+
+	return data, nil
+}
+
+// acquire takes the lock, waiting up to LockTimeout for another holder
+func acquire(name string) (*fileLock, error) {
+	deadline := time.Now().Add(LockTimeout)
+
+	for {
+		lock, ok, err := tryLock(name)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return lock, nil
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%w: %s", ErrLocked, name)
+		}
+		time.Sleep(lockRetry)
+	}
+}
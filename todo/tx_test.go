@@ -0,0 +1,223 @@
+package todo_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ankitjha420/todo"
+)
+
+func TestTxCommit(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "todo.json")
+
+	tx, err := todo.Begin(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tx.List) != 0 {
+		t.Errorf("expected length 0 but got %d", len(tx.List))
+	}
+
+	tx.List.Add("new task")
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err == nil {
+		t.Error("expected an error committing twice")
+	}
+
+	l := todo.List{}
+	if err := l.Get(fname); err != nil {
+		t.Fatal(err)
+	}
+	if len(l) != 1 || l[0].Task != "new task" {
+		t.Errorf("expected the committed task but got %v", l)
+	}
+}
+
+func TestTxRollback(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "todo.json")
+
+	tx, err := todo.Begin(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.List.Add("new task")
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(fname); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected no file after a rollback but got %v", err)
+	}
+
+	// the lock is free again
+	tx, err = todo.Begin(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTxModified(t *testing.T) {
+	testCases := []struct {
+		name    string
+		initial string
+		change  func(fname string) error
+	}{
+		{
+			name:    "Changed",
+			initial: "[]",
+			change:  func(fname string) error { return os.WriteFile(fname, []byte(`[{"task": "theirs"}]`), 0644) },
+		},
+		{
+			name:   "Created",
+			change: func(fname string) error { return os.WriteFile(fname, []byte(`[]`), 0644) },
+		},
+		{
+			name:    "Removed",
+			initial: "[]",
+			change:  os.Remove,
+		},
+		{
+			name:    "Emptied",
+			initial: "[]",
+			change:  func(fname string) error { return os.WriteFile(fname, nil, 0644) },
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fname := filepath.Join(t.TempDir(), "todo.json")
+			if tc.initial != "" {
+				if err := os.WriteFile(fname, []byte(tc.initial), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			tx, err := todo.Begin(fname)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tx.List.Add("ours")
+
+			if err := tc.change(fname); err != nil {
+				t.Fatal(err)
+			}
+
+			before, _ := os.ReadFile(fname)
+			if err := tx.Commit(); !errors.Is(err, todo.ErrModified) {
+				t.Fatalf("expected error %q but got %v", todo.ErrModified, err)
+			}
+
+			after, _ := os.ReadFile(fname)
+			if string(after) != string(before) {
+				t.Errorf("expected %q to be left alone but got %q", before, after)
+			}
+		})
+	}
+}
+
+func TestTxLocked(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "todo.json")
+
+	defer func(timeout time.Duration) { todo.LockTimeout = timeout }(todo.LockTimeout)
+	todo.LockTimeout = 50 * time.Millisecond
+
+	tx, err := todo.Begin(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	if _, err := todo.Begin(fname); !errors.Is(err, todo.ErrLocked) {
+		t.Errorf("expected error %q but got %v", todo.ErrLocked, err)
+	}
+}
+
+func TestUpdateConcurrent(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "todo.json")
+	n := 20
+
+	var wg sync.WaitGroup
+	errs := make([]error, n)
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = todo.Update(fname, func(l *todo.List) error {
+				l.Add(fmt.Sprintf("task %d", i))
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	l := todo.List{}
+	if err := l.Get(fname); err != nil {
+		t.Fatal(err)
+	}
+	if len(l) != n {
+		t.Errorf("expected length %d but got %d", n, len(l))
+	}
+}
+
+func TestUpdateError(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "todo.json")
+	fail := errors.New("fail")
+
+	err := todo.Update(fname, func(l *todo.List) error {
+		l.Add("new task")
+		return fail
+	})
+	if !errors.Is(err, fail) {
+		t.Errorf("expected error %q but got %v", fail, err)
+	}
+	if _, err := os.Stat(fname); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected nothing saved but got %v", err)
+	}
+}
+
+func TestSaveAtomic(t *testing.T) {
+	dir := t.TempDir()
+	fname := filepath.Join(dir, "todo.json")
+
+	if err := os.WriteFile(fname, []byte("[]"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	l := todo.List{}
+	l.Add("new task")
+	if err := l.Save(fname); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected only the saved file but got %d entries", len(entries))
+	}
+
+	info, err := os.Stat(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected the mode to be kept but got %v", info.Mode().Perm())
+	}
+}